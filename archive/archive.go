@@ -0,0 +1,271 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/payload"
+)
+
+// Extension is the file extension of export archives, a zstd compressed tar file
+const Extension = ".tar.zst"
+
+// Write streams the files of the export directory followed by the referenced package and image files into the archive.
+// Package and image files are read from their source, the export directory holds the SQL file and the metadata only.
+func Write(archiveFile string, exportDir string, entries []payload.Entry) error {
+	file, err := os.Create(archiveFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	zstdWriter, err := zstd.NewWriter(file)
+	if err != nil {
+		return err
+	}
+	defer zstdWriter.Close()
+	tarWriter := tar.NewWriter(zstdWriter)
+	defer tarWriter.Close()
+
+	metadataFiles := make([]string, 0)
+	err = filepath.WalkDir(exportDir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relativePath, err := filepath.Rel(exportDir, filePath)
+		if err != nil {
+			return err
+		}
+		metadataFiles = append(metadataFiles, filepath.ToSlash(relativePath))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(metadataFiles)
+	for _, relativePath := range metadataFiles {
+		if err := addFile(tarWriter, filepath.Join(exportDir, filepath.FromSlash(relativePath)), relativePath, -1); err != nil {
+			return err
+		}
+	}
+
+	// a resumed export can list a file twice
+	added := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if added[entry.Destination] {
+			continue
+		}
+		added[entry.Destination] = true
+		if err := addFile(tarWriter, entry.Source, entry.Destination, entry.Size); err != nil {
+			return err
+		}
+	}
+	log.Info().Msgf("%d files and %d package and image files written to %s", len(metadataFiles), len(added), archiveFile)
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	if err := zstdWriter.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// addFile adds the file as name, expectedSize is checked unless it is negative
+func addFile(tarWriter *tar.Writer, fileName string, name string, expectedSize int64) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if expectedSize >= 0 && info.Size() != expectedSize {
+		return fmt.Errorf("%s changed since it was exported, size %d, expected %d", fileName, info.Size(), expectedSize)
+	}
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     info.Size(),
+		Mode:     0644,
+		ModTime:  info.ModTime().Truncate(time.Second),
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tarWriter, file)
+	return err
+}
+
+// reader reads the entries of an archive in order
+type reader struct {
+	*tar.Reader
+	file    *os.File
+	decoder *zstd.Decoder
+}
+
+func open(archiveFile string) (*reader, error) {
+	file, err := os.Open(archiveFile)
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := zstd.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &reader{tar.NewReader(decoder), file, decoder}, nil
+}
+
+func (r *reader) Close() error {
+	r.decoder.Close()
+	return r.file.Close()
+}
+
+// next returns the next file of the archive and its cleaned name, other entries are skipped
+func (r *reader) next() (*tar.Header, string, error) {
+	for {
+		header, err := r.Next()
+		if err != nil {
+			return nil, "", err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, "", fmt.Errorf("invalid file name %s in archive", header.Name)
+		}
+		return header, name, nil
+	}
+}
+
+// ReadFile returns the content of the file of the archive
+func ReadFile(archiveFile string, name string) ([]byte, error) {
+	r, err := open(archiveFile)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	for {
+		_, entryName, err := r.next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s not found in %s: %w", name, archiveFile, fs.ErrNotExist)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entryName == name {
+			return io.ReadAll(r)
+		}
+	}
+}
+
+// Extract reads the archive once. The SQL file and the metadata are extracted into the staging directory, then
+// metadataExtracted is called to verify them. Package and image files are passed to payloadFile while reading,
+// they need to be listed in the extracted payload file list.
+func Extract(archiveFile string, stagingDir string, metadataExtracted func() error,
+	payloadFile func(entry payload.Entry, content io.Reader) error) error {
+	r, err := open(archiveFile)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var entries map[string]payload.Entry
+	received := make(map[string]bool)
+	finishMetadata := func() error {
+		listed, err := payload.Read(stagingDir)
+		if err != nil {
+			return err
+		}
+		entries = make(map[string]payload.Entry, len(listed))
+		for _, entry := range listed {
+			entries[entry.Destination] = entry
+		}
+		return metadataExtracted()
+	}
+
+	for {
+		_, name, err := r.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !payload.IsPayloadPath(name) {
+			if entries != nil {
+				return fmt.Errorf("unexpected file %s after package and image files", name)
+			}
+			if err := extractFile(r, path.Join(stagingDir, name)); err != nil {
+				return err
+			}
+			continue
+		}
+		if entries == nil {
+			if err := finishMetadata(); err != nil {
+				return err
+			}
+		}
+		entry, ok := entries[name]
+		if !ok {
+			return fmt.Errorf("%s: not listed in %s", name, payload.FileListName)
+		}
+		if received[name] {
+			return fmt.Errorf("%s: stored twice", name)
+		}
+		received[name] = true
+		if err := payloadFile(entry, r); err != nil {
+			return err
+		}
+	}
+	if entries == nil {
+		if err := finishMetadata(); err != nil {
+			return err
+		}
+	}
+
+	missing := make([]string, 0)
+	for name := range entries {
+		if !received[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("%d package and image files missing in the archive: %s", len(missing), strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func extractFile(content io.Reader, target string) error {
+	if err := os.MkdirAll(path.Dir(target), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := io.Copy(file, content); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/uyuni-project/inter-server-sync/payload"
+)
+
+func writeFile(t *testing.T, fileName string, content string) {
+	if err := os.MkdirAll(path.Dir(fileName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fileName, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createExport creates an export directory referencing a package and an image file
+func createExport(t *testing.T) (string, []payload.Entry) {
+	sourceDir := t.TempDir()
+	exportDir := t.TempDir()
+	writeFile(t, path.Join(sourceDir, "packages/1/a.rpm"), "package a")
+	writeFile(t, path.Join(sourceDir, "os-images/1/image.xz"), "image")
+	writeFile(t, path.Join(exportDir, "sql_statements.sql.gz"), "sql")
+	writeFile(t, path.Join(exportDir, "export_state.json"), "{}")
+
+	list, err := payload.Create(exportDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sha256 of "package a"
+	if err := list.AddPackage(path.Join(sourceDir, "packages/1/a.rpm"), "packages/1/a.rpm", "sha256",
+		"7663fa2eaf2e6846391a250cc37947941ffda1650e53cdee850c32f56e277971"); err != nil {
+		t.Fatal(err)
+	}
+	if err := list.AddImage(path.Join(sourceDir, "os-images/1/image.xz"), "1/image.xz"); err != nil {
+		t.Fatal(err)
+	}
+	if err := list.Close(); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := payload.Read(exportDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exportDir, entries
+}
+
+func TestArchive(t *testing.T) {
+	exportDir, entries := createExport(t)
+	archiveFile := path.Join(t.TempDir(), "export"+Extension)
+	if err := Write(archiveFile, exportDir, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if state, err := ReadFile(archiveFile, "export_state.json"); err != nil || string(state) != "{}" {
+		t.Errorf("unexpected export state %q %v", state, err)
+	}
+	if _, err := ReadFile(archiveFile, "missing.json"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("unexpected error for a missing file %v", err)
+	}
+
+	stagingDir := t.TempDir()
+	metadataExtracted := false
+	payloadFiles := make(map[string]string)
+	err := Extract(archiveFile, stagingDir, func() error {
+		// all metadata is extracted before the first package or image file
+		if len(payloadFiles) > 0 {
+			t.Error("metadata extracted after package and image files")
+		}
+		metadataExtracted = true
+		return nil
+	}, func(entry payload.Entry, content io.Reader) error {
+		data, err := io.ReadAll(content)
+		payloadFiles[entry.Destination] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !metadataExtracted {
+		t.Error("metadata not verified")
+	}
+	expected := map[string]string{"packages/1/a.rpm": "package a", "images/1/image.xz": "image"}
+	if !reflect.DeepEqual(payloadFiles, expected) {
+		t.Errorf("unexpected package and image files %v", payloadFiles)
+	}
+	for _, name := range []string{"sql_statements.sql.gz", payload.FileListName, payload.PackageListName} {
+		if _, err := os.Stat(path.Join(stagingDir, name)); err != nil {
+			t.Errorf("%s not extracted: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(path.Join(stagingDir, "packages")); !os.IsNotExist(err) {
+		t.Error("package files extracted into the staging directory")
+	}
+
+	// a failed verification stops before any package or image file is read
+	err = Extract(archiveFile, t.TempDir(), func() error {
+		return errors.New("invalid signature")
+	}, func(entry payload.Entry, content io.Reader) error {
+		t.Errorf("unexpected package or image file %s", entry.Destination)
+		return nil
+	})
+	if err == nil || err.Error() != "invalid signature" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestWriteChangedFile(t *testing.T) {
+	exportDir, entries := createExport(t)
+	writeFile(t, entries[0].Source, "package a changed")
+	if err := Write(path.Join(t.TempDir(), "export"+Extension), exportDir, entries); err == nil ||
+		!strings.Contains(err.Error(), "changed since it was exported") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestExtractInvalidArchive(t *testing.T) {
+	exportDir, _ := createExport(t)
+	tests := map[string][]string{
+		"invalid file name":                 {"../sql_statements.sql.gz"},
+		"not listed":                        {payload.FileListName, "packages/1/b.rpm"},
+		"after package":                     {payload.FileListName, "packages/1/a.rpm", "version.txt"},
+		"1 package and image files missing": {payload.FileListName, "packages/1/a.rpm"},
+		"2 package and image files missing": {payload.FileListName},
+	}
+	for expected, names := range tests {
+		archiveFile := path.Join(t.TempDir(), "export"+Extension)
+		file, _ := os.Create(archiveFile)
+		zstdWriter, _ := zstd.NewWriter(file)
+		tarWriter := tar.NewWriter(zstdWriter)
+		for _, name := range names {
+			content := []byte("package a")
+			if name == payload.FileListName {
+				content, _ = os.ReadFile(path.Join(exportDir, name))
+			}
+			tarWriter.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: int64(len(content)), Mode: 0644})
+			tarWriter.Write(content)
+		}
+		tarWriter.Close()
+		zstdWriter.Close()
+		file.Close()
+
+		err := Extract(archiveFile, t.TempDir(), func() error { return nil },
+			func(entry payload.Entry, content io.Reader) error { return nil })
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%v: unexpected error %v", names, err)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/archive"
+	"github.com/uyuni-project/inter-server-sync/payload"
+	"github.com/uyuni-project/inter-server-sync/utils"
+)
+
+// payloadTarget is where package or image files of an archive are written to on the target server
+type payloadTarget struct {
+	prefix string
+	dir    string
+	user   string
+	group  string
+	uid    int
+	gid    int
+}
+
+// payloadTargets match the directories and owners the rsync of exported package and image files uses
+var payloadTargets = []*payloadTarget{
+	{prefix: "packages/", dir: "/var/spacewalk/packages", user: "wwwrun", group: "www", uid: -1},
+	{prefix: payload.ImagesDir + "/", dir: "/srv/www/os-images", user: "salt", group: "susemanager", uid: -1},
+}
+
+// lookupOwner resolves the owner of the target once
+func (t *payloadTarget) lookupOwner() error {
+	if t.uid >= 0 {
+		return nil
+	}
+	owner, err := user.Lookup(t.user)
+	if err != nil {
+		return err
+	}
+	group, err := user.LookupGroup(t.group)
+	if err != nil {
+		return err
+	}
+	if t.gid, err = strconv.Atoi(group.Gid); err != nil {
+		return err
+	}
+	t.uid, err = strconv.Atoi(owner.Uid)
+	return err
+}
+
+// mkdirAll creates the missing directories owned by the owner of the target
+func (t *payloadTarget) mkdirAll(dir string) error {
+	if _, err := os.Stat(dir); err == nil || !os.IsNotExist(err) {
+		return err
+	}
+	if err := t.mkdirAll(path.Dir(dir)); err != nil {
+		return err
+	}
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return err
+	}
+	return os.Chown(dir, t.uid, t.gid)
+}
+
+// pendingSuffix marks package and image files of an archive written next to their target until the SQL import finished
+const pendingSuffix = ".iss-pending"
+
+// write writes the verified package or image file of the archive next to its place on the target server,
+// it returns the target the file is moved to once the SQL import finished
+func (t *payloadTarget) write(entry payload.Entry, content io.Reader) (string, error) {
+	target := path.Join(t.dir, strings.TrimPrefix(entry.Destination, t.prefix))
+	if err := t.lookupOwner(); err != nil {
+		return "", fmt.Errorf("%s: %w", entry.Destination, err)
+	}
+	if err := t.mkdirAll(path.Dir(target)); err != nil {
+		return "", err
+	}
+	if err := entry.Write(content, target+pendingSuffix); err != nil {
+		return "", err
+	}
+	return target, os.Chown(target+pendingSuffix, t.uid, t.gid)
+}
+
+// pendingPayload are the package and image files of an archive waiting for the SQL import
+type pendingPayload struct {
+	lock    sync.Mutex
+	targets []string
+}
+
+func (p *pendingPayload) add(target string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.targets = append(p.targets, target)
+}
+
+// remove deletes the files which were not moved into place
+func (p *pendingPayload) remove() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, target := range p.targets {
+		os.Remove(target + pendingSuffix)
+	}
+	p.targets = nil
+}
+
+// move moves the files into place, the files not moved yet are kept on failure
+func (p *pendingPayload) move() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for len(p.targets) > 0 {
+		if err := os.Rename(p.targets[0]+pendingSuffix, p.targets[0]); err != nil {
+			return err
+		}
+		p.targets = p.targets[1:]
+	}
+	return nil
+}
+
+func findPayloadTarget(destination string) *payloadTarget {
+	for _, target := range payloadTargets {
+		if strings.HasPrefix(destination, target.prefix) {
+			return target
+		}
+	}
+	return nil
+}
+
+// createArchiveStaging creates the directory next to the archive the SQL file and the metadata are exported into.
+// It is removed by runCleanups.
+func createArchiveStaging(archiveFile string) string {
+	stagingDir, err := os.MkdirTemp(path.Dir(utils.GetAbsPath(archiveFile)), "iss-export-")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to create the staging directory for the archive")
+	}
+	removeOnExit(stagingDir)
+	return stagingDir
+}
+
+// writeArchive streams the exported files and the referenced package and image files into the archive
+func writeArchive(archiveFile string, outputFolderAbs string) {
+	entries, err := payload.Read(outputFolderAbs)
+	if err != nil {
+		log.Panic().Err(err).Msg("error reading referenced package and image files")
+	}
+	log.Info().Msgf("Writing archive %s", archiveFile)
+	if err := archive.Write(archiveFile, outputFolderAbs, entries); err != nil {
+		os.Remove(archiveFile)
+		log.Panic().Err(err).Msg("error writing archive")
+	}
+}
+
+// extractArchive extracts the SQL file and the metadata of the archive into a staging directory and returns it.
+// The SQL file and the metadata are written to disk completely, they are small compared to package and image files.
+// Package and image files are written next to their place on the server, or only verified on a dry run,
+// once the signatures and the metadata were verified by prepare. The returned function moves them into place
+// and needs to be called once the SQL import finished, otherwise they are removed by runCleanups
+// like the staging directory.
+func extractArchive(absArchiveFile string, stagingDir string, prepare func(absImportDir string)) (string, func()) {
+	var err error
+	if len(stagingDir) == 0 {
+		stagingDir, err = os.MkdirTemp(path.Dir(absArchiveFile), "iss-import-")
+	} else {
+		err = os.MkdirAll(stagingDir, 0700)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to create the staging directory for the archive")
+	}
+	stagingDir = utils.GetAbsPath(stagingDir)
+	removeOnExit(stagingDir)
+	log.Info().Msgf("Extracting archive %s into %s", absArchiveFile, stagingDir)
+
+	pending := &pendingPayload{}
+	addCleanup(pending.remove)
+	written := 0
+	err = archive.Extract(absArchiveFile, stagingDir, func() error {
+		prepare(stagingDir)
+		return nil
+	}, func(entry payload.Entry, content io.Reader) error {
+		written++
+		if dryRun {
+			return entry.Write(content, "")
+		}
+		target := findPayloadTarget(entry.Destination)
+		if target == nil {
+			return fmt.Errorf("%s: unknown destination", entry.Destination)
+		}
+		file, err := target.write(entry, content)
+		if len(file) > 0 {
+			pending.add(file)
+		}
+		return err
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Unable to import archive %s", absArchiveFile)
+	}
+	if dryRun {
+		log.Info().Msgf("%d package and image files of the archive verified", written)
+	} else {
+		log.Info().Msgf("%d package and image files of the archive written, they are moved into place after the SQL import", written)
+	}
+	return stagingDir, func() {
+		if err := pending.move(); err != nil {
+			log.Fatal().Err(err).Msg("Unable to move the package and image files of the archive into place")
+		}
+		log.Info().Msgf("%d package and image files of the archive moved into place", written)
+	}
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/uyuni-project/inter-server-sync/payload"
+)
+
+func TestPendingPayload(t *testing.T) {
+	dir := t.TempDir()
+	target := &payloadTarget{prefix: "packages/", dir: dir, uid: os.Getuid(), gid: os.Getgid()}
+	// sha256 of "package a"
+	entry := payload.Entry{Destination: "packages/1/a.rpm", Size: 9,
+		Checksum: "sha256:7663fa2eaf2e6846391a250cc37947941ffda1650e53cdee850c32f56e277971"}
+	targetFile := path.Join(dir, "1/a.rpm")
+
+	write := func() *pendingPayload {
+		pending := &pendingPayload{}
+		file, err := target.write(entry, strings.NewReader("package a"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pending.add(file)
+		if _, err := os.Stat(targetFile); !os.IsNotExist(err) {
+			t.Error("file moved into place before the SQL import")
+		}
+		return pending
+	}
+
+	// a failed import removes the written files
+	write().remove()
+	if entries, _ := os.ReadDir(path.Join(dir, "1")); len(entries) > 0 {
+		t.Errorf("files left after removing: %v", entries)
+	}
+
+	pending := write()
+	if err := pending.move(); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := os.ReadFile(targetFile); err != nil || string(content) != "package a" {
+		t.Errorf("unexpected content %q %v", content, err)
+	}
+	pending.remove()
+	if _, err := os.Stat(targetFile); err != nil {
+		t.Error("moved file removed")
+	}
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/cobbler"
+	"github.com/uyuni-project/inter-server-sync/utils"
+)
+
+// readImportedAutoinstall reads the exported distributions and profiles and their autoinstallation files.
+// Organizations of the profiles are mapped like the SQL statements, profiles are identified by label and organization.
+func readImportedAutoinstall(absImportDir string, mapping orgMapping) (cobbler.ImportedAutoinstall, error) {
+	autoinstall := cobbler.ImportedAutoinstall{}
+	readLabels := func(fileName string) []string {
+		labels := make([]string, 0)
+		if _, err := os.Stat(path.Join(absImportDir, fileName)); err != nil {
+			return labels
+		}
+		for _, label := range utils.ReadFileByLine(path.Join(absImportDir, fileName)) {
+			if len(label) > 0 {
+				labels = append(labels, label)
+			}
+		}
+		return labels
+	}
+	autoinstall.Distributions = readLabels("exportedDistributions.txt")
+	autoinstall.Profiles = make([]cobbler.ImportedProfile, 0)
+	profileLabels := readLabels("exportedAutoinstallProfiles.txt")
+
+	content, err := os.ReadFile(path.Join(absImportDir, cobbler.AutoinstallFileListName))
+	if os.IsNotExist(err) {
+		if len(profileLabels) > 0 {
+			log.Warn().Msgf("Organizations of the autoinstallation profiles are not exported, no cobbler profiles are created for %s",
+				strings.Join(profileLabels, ", "))
+		}
+		return autoinstall, nil
+	}
+	if err != nil {
+		return autoinstall, err
+	}
+	exportedFiles := make([]cobbler.AutoinstallFile, 0)
+	if err := json.Unmarshal(content, &exportedFiles); err != nil {
+		return autoinstall, fmt.Errorf("invalid %s: %w", cobbler.AutoinstallFileListName, err)
+	}
+
+	files := make(map[[2]string]string, len(exportedFiles))
+	for _, file := range exportedFiles {
+		org := file.Org
+		if target, ok := mapping[org]; ok {
+			org = target
+		}
+		autoinstall.Profiles = append(autoinstall.Profiles, cobbler.ImportedProfile{Label: file.Label, Org: org})
+		if len(file.Path) == 0 {
+			continue
+		}
+		if !filepath.IsLocal(file.Path) {
+			return autoinstall, fmt.Errorf("invalid autoinstallation file %s of profile %s", file.Path, file.Label)
+		}
+		files[[2]string{file.Label, org}] = path.Join(absImportDir, file.Path)
+	}
+	autoinstall.ProfileFile = func(label string, org string) string {
+		return files[[2]string{label, org}]
+	}
+	return autoinstall, nil
+}
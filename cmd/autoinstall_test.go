@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/uyuni-project/inter-server-sync/cobbler"
+)
+
+func TestReadImportedAutoinstall(t *testing.T) {
+	importDir := t.TempDir()
+	os.WriteFile(path.Join(importDir, "exportedAutoinstallProfiles.txt"), []byte("sles\nrhel\n"), 0644)
+
+	// profiles without organization cannot be identified on the target server
+	autoinstall, err := readImportedAutoinstall(importDir, orgMapping{})
+	if err != nil || len(autoinstall.Profiles) != 0 {
+		t.Errorf("unexpected profiles without organization %v: %v", autoinstall.Profiles, err)
+	}
+
+	os.WriteFile(path.Join(importDir, cobbler.AutoinstallFileListName),
+		[]byte(`[{"label": "sles", "org": "Source Org", "path": "autoinstall/sles--1.cfg"}, {"label": "rhel", "org": "Org"}]`), 0644)
+
+	autoinstall, err = readImportedAutoinstall(importDir, orgMapping{"Source Org": "Target Org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []cobbler.ImportedProfile{{Label: "sles", Org: "Target Org"}, {Label: "rhel", Org: "Org"}}
+	if len(autoinstall.Distributions) != 0 || !reflect.DeepEqual(autoinstall.Profiles, expected) {
+		t.Errorf("unexpected distributions %v and profiles %v", autoinstall.Distributions, autoinstall.Profiles)
+	}
+	if file := autoinstall.ProfileFile("sles", "Target Org"); file != path.Join(importDir, "autoinstall/sles--1.cfg") {
+		t.Errorf("unexpected autoinstallation file %q", file)
+	}
+	if file := autoinstall.ProfileFile("sles", "Source Org"); file != "" {
+		t.Errorf("unexpected autoinstallation file of the unmapped organization %q", file)
+	}
+	if file := autoinstall.ProfileFile("rhel", "Org"); file != "" {
+		t.Errorf("unexpected autoinstallation file of a profile without file %q", file)
+	}
+
+	os.WriteFile(path.Join(importDir, cobbler.AutoinstallFileListName),
+		[]byte(`[{"label": "sles", "org": "Source Org", "path": "../sles--1.cfg"}]`), 0644)
+	if _, err := readImportedAutoinstall(importDir, orgMapping{}); err == nil {
+		t.Error("autoinstallation file outside of the import accepted")
+	}
+}
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// cleanups remove temporary files of the running command. log.Fatal exits without running deferred functions,
+// so they are run by fatalCleanupHook as well.
+var cleanups []func()
+var cleanupsLock sync.Mutex
+
+// addCleanup registers a cleanup run by runCleanups
+func addCleanup(cleanup func()) {
+	cleanupsLock.Lock()
+	defer cleanupsLock.Unlock()
+	cleanups = append(cleanups, cleanup)
+}
+
+// removeOnExit removes the temporary directory once the command ends, also when it fails
+func removeOnExit(dir string) {
+	addCleanup(func() { os.RemoveAll(dir) })
+}
+
+// runCleanups runs the registered cleanups in reverse order, each one once
+func runCleanups() {
+	cleanupsLock.Lock()
+	pending := cleanups
+	cleanups = nil
+	cleanupsLock.Unlock()
+	for i := len(pending) - 1; i >= 0; i-- {
+		pending[i]()
+	}
+}
+
+// fatalCleanupHook runs the cleanups before log.Fatal exits
+type fatalCleanupHook struct{}
+
+func (fatalCleanupHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	if level == zerolog.FatalLevel {
+		runCleanups()
+	}
+}
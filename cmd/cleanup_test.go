@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestRunCleanups(t *testing.T) {
+	dir, err := os.MkdirTemp(t.TempDir(), "iss-import-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := make([]int, 0)
+	addCleanup(func() { order = append(order, 1) })
+	removeOnExit(dir)
+	addCleanup(func() { order = append(order, 2) })
+
+	fatalCleanupHook{}.Run(nil, zerolog.ErrorLevel, "")
+	if len(order) > 0 {
+		t.Error("cleanups run for an error")
+	}
+	fatalCleanupHook{}.Run(nil, zerolog.FatalLevel, "")
+	if !reflect.DeepEqual(order, []int{2, 1}) {
+		t.Errorf("unexpected order %v", order)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("temporary directory not removed")
+	}
+
+	runCleanups()
+	if len(order) != 2 {
+		t.Errorf("cleanups run twice %v", order)
+	}
+}
@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+// tableChanges counts the rows changed in a table
+type tableChanges struct {
+	inserted int64
+	updated  int64
+	deleted  int64
+}
+
+// dryRunChanges collects the changes of the import per table
+type dryRunChanges map[string]*tableChanges
+
+func (c dryRunChanges) table(tableName string) *tableChanges {
+	changes, ok := c[tableName]
+	if !ok {
+		changes = &tableChanges{}
+		c[tableName] = changes
+	}
+	return changes
+}
+
+// isTransactionStatement checks for statements controlling the transaction of the export.
+// Dry run uses its own transaction, these statements are skipped.
+func isTransactionStatement(statement string) bool {
+	switch sqlUtil.StatementType(statement) {
+	case "BEGIN", "START", "COMMIT", "END", "ROLLBACK":
+		return true
+	}
+	return false
+}
+
+// dryRunStatement returns the statement to execute to count the changed rows.
+// Inserts return whether each row was inserted or, by ON CONFLICT DO UPDATE, updated.
+func dryRunStatement(statement string) (string, bool) {
+	if sqlUtil.StatementType(statement) != "INSERT" || strings.Contains(strings.ToUpper(statement), "RETURNING") {
+		return statement, false
+	}
+	return statement + " RETURNING (xmax = 0) AS inserted", true
+}
+
+// execute runs the statement and counts the changed rows
+func (c dryRunChanges) execute(ctx context.Context, conn *sql.Conn, statement string) error {
+	if isTransactionStatement(statement) {
+		return nil
+	}
+	tableName := sqlUtil.StatementTable(statement)
+	query, returning := dryRunStatement(statement)
+	if returning {
+		rows, err := conn.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		changes := c.table(tableName)
+		for rows.Next() {
+			var inserted bool
+			if err := rows.Scan(&inserted); err != nil {
+				return err
+			}
+			if inserted {
+				changes.inserted++
+			} else {
+				changes.updated++
+			}
+		}
+		return rows.Err()
+	}
+
+	result, err := conn.ExecContext(ctx, statement)
+	if err != nil {
+		return err
+	}
+	if len(tableName) == 0 {
+		return nil
+	}
+	if affected, err := result.RowsAffected(); err == nil {
+		switch sqlUtil.StatementType(statement) {
+		case "INSERT":
+			c.table(tableName).inserted += affected
+		case "UPDATE":
+			c.table(tableName).updated += affected
+		case "DELETE":
+			c.table(tableName).deleted += affected
+		}
+	}
+	return nil
+}
+
+// print writes the changes per table and the totals
+func (c dryRunChanges) print(writer io.Writer) {
+	tableNames := make([]string, 0, len(c))
+	for tableName := range c {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+
+	total := tableChanges{}
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TABLE\tINSERTED\tUPDATED\tDELETED")
+	for _, tableName := range tableNames {
+		changes := c[tableName]
+		if changes.inserted == 0 && changes.updated == 0 && changes.deleted == 0 {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", tableName, changes.inserted, changes.updated, changes.deleted)
+		total.inserted += changes.inserted
+		total.updated += changes.updated
+		total.deleted += changes.deleted
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\t%d\t%d\n", total.inserted, total.updated, total.deleted)
+	tw.Flush()
+}
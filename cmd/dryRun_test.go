@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestDryRunStatement(t *testing.T) {
+	insert := "INSERT INTO rhnchannel (label) VALUES ('test') ON CONFLICT (label) DO UPDATE SET label = excluded.label"
+	if query, returning := dryRunStatement(insert); !returning || query != insert+" RETURNING (xmax = 0) AS inserted" {
+		t.Errorf("unexpected dry run statement for insert: %s", query)
+	}
+	update := "UPDATE rhnchannel SET summary = 'x'"
+	if query, returning := dryRunStatement(update); returning || query != update {
+		t.Errorf("unexpected dry run statement for update: %s", query)
+	}
+}
+
+func TestIsTransactionStatement(t *testing.T) {
+	for _, statement := range []string{"BEGIN", "begin", "COMMIT", "START TRANSACTION"} {
+		if !isTransactionStatement(statement) {
+			t.Errorf("%s should be a transaction statement", statement)
+		}
+	}
+	for _, statement := range []string{"INSERT INTO rhnchannel (label) VALUES ('commit')", "DO $$ BEGIN END $$"} {
+		if isTransactionStatement(statement) {
+			t.Errorf("%s should not be a transaction statement", statement)
+		}
+	}
+}
+
+func TestDryRunChangesExecute(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	insert := "INSERT INTO rhnchannel (label) VALUES ('a'), ('b'), ('c') ON CONFLICT (label) DO UPDATE SET label = excluded.label"
+	mock.ExpectQuery(insert + " RETURNING (xmax = 0) AS inserted").
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true).AddRow(false).AddRow(true))
+	mock.ExpectExec("DELETE FROM rhnchannelpackage WHERE 1 = 1").WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec("UPDATE rhnchannel SET summary = 'x'").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := make(dryRunChanges)
+	for _, statement := range []string{"BEGIN", insert, "DELETE FROM rhnchannelpackage WHERE 1 = 1",
+		"UPDATE rhnchannel SET summary = 'x'", "COMMIT"} {
+		if err := changes.execute(ctx, conn, statement); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	if *changes["rhnchannel"] != (tableChanges{inserted: 2, updated: 2}) {
+		t.Errorf("unexpected rhnchannel changes: %v", *changes["rhnchannel"])
+	}
+	if *changes["rhnchannelpackage"] != (tableChanges{deleted: 4}) {
+		t.Errorf("unexpected rhnchannelpackage changes: %v", *changes["rhnchannelpackage"])
+	}
+
+	var output strings.Builder
+	changes.print(&output)
+	expected := `TABLE              INSERTED  UPDATED  DELETED
+rhnchannel         2         2        0
+rhnchannelpackage  0         0        4
+TOTAL              2         2        4
+`
+	if output.String() != expected {
+		t.Errorf("unexpected report:\n%s", output.String())
+	}
+}
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/encryption"
+)
+
+// encryptExport encrypts all files of the export, it needs to run after the files were signed so signatures cover the plain text
+func encryptExport(outputFolderAbs string, encryptTo string, encryptPassFile string) {
+	var header *encryption.Header
+	var key []byte
+	var err error
+	if len(encryptTo) > 0 {
+		header, key, err = encryption.NewCertificateKey(encryptTo)
+	} else {
+		header, key, err = encryption.NewPassphraseKey(encryptPassFile)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to create the export encryption key")
+	}
+	log.Info().Msg("Encrypting exported files")
+	if err := encryption.EncryptDir(outputFolderAbs, header, key); err != nil {
+		log.Panic().Err(err).Msg("error encrypting exported files")
+	}
+}
+
+// decryptImport decrypts an encrypted export into a staging directory and returns the directory to import from.
+// Unencrypted exports are imported directly. The staging directory is removed by runCleanups.
+func decryptImport(absImportDir string, decryptKey string, keyPassfile string, stagingDir string) string {
+	header, err := encryption.ReadHeader(absImportDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to read the export encryption")
+	}
+	if header == nil {
+		return absImportDir
+	}
+	if len(decryptKey) == 0 {
+		log.Fatal().Msgf("Export is encrypted with a %s, please use `--decryptKey` to decrypt it", header.Method)
+	}
+	key, err := header.Key(decryptKey, keyPassfile)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to decrypt the export")
+	}
+	if len(stagingDir) == 0 {
+		stagingDir, err = os.MkdirTemp(path.Dir(absImportDir), "iss-import-")
+	} else {
+		err = os.MkdirAll(stagingDir, 0700)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to create the staging directory for the decrypted export")
+	}
+	removeOnExit(stagingDir)
+	log.Info().Msgf("Decrypting export into %s", stagingDir)
+	if err := encryption.DecryptDir(absImportDir, stagingDir, key); err != nil {
+		log.Fatal().Err(err).Msg("Unable to decrypt the export")
+	}
+	return stagingDir
+}
@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+// entitySharedKind is the kind of the statements outside of marked entities, like products or errata
+const entitySharedKind = "shared"
+
+// entityResult is the outcome of the import of a single entity
+type entityResult struct {
+	kind       string
+	label      string
+	statements int
+	err        error
+}
+
+// entityImport executes the statements of every marked entity in its own transaction.
+// Statements between entities are grouped into shared units with their own transaction as well.
+// A failing statement rolls back its entity only, the rest of the entity is skipped and the import continues.
+type entityImport struct {
+	ctx           context.Context
+	conn          *sql.Conn
+	current       *entityResult
+	inTransaction bool
+	sharedUnits   int
+	results       []*entityResult
+}
+
+func newEntityImport(ctx context.Context, conn *sql.Conn) *entityImport {
+	return &entityImport{ctx: ctx, conn: conn, results: make([]*entityResult, 0)}
+}
+
+// mark finishes the current entity and starts the new one when the marker begins an entity
+func (e *entityImport) mark(marker sqlUtil.EntityMarker) {
+	e.finish()
+	if marker.Begin {
+		e.current = &entityResult{kind: marker.Kind, label: marker.Label}
+	}
+}
+
+func (e *entityImport) execute(statement string, number int) {
+	// transactions are handled per entity
+	if isTransactionStatement(statement) {
+		return
+	}
+	if e.current == nil {
+		e.sharedUnits++
+		e.current = &entityResult{kind: entitySharedKind, label: fmt.Sprintf("#%d", e.sharedUnits)}
+	}
+	if e.current.err != nil {
+		return
+	}
+	if !e.inTransaction {
+		if _, err := e.conn.ExecContext(e.ctx, "BEGIN"); err != nil {
+			e.fail(err)
+			return
+		}
+		e.inTransaction = true
+	}
+	if _, err := e.conn.ExecContext(e.ctx, statement); err != nil {
+		log.Error().Msgf("Failing statement: %s", statementExcerpt(statement))
+		e.fail(&statementError{number: number, table: sqlUtil.StatementTable(statement), err: err})
+		return
+	}
+	e.current.statements++
+}
+
+func (e *entityImport) fail(err error) {
+	if e.inTransaction {
+		e.conn.ExecContext(e.ctx, "ROLLBACK")
+		e.inTransaction = false
+	}
+	e.current.err = err
+	log.Error().Err(err).Msgf("Import of %s %s failed, continuing with the next entity", e.current.kind, e.current.label)
+}
+
+// finish commits the current entity
+func (e *entityImport) finish() {
+	if e.current == nil {
+		return
+	}
+	if e.inTransaction {
+		if _, err := e.conn.ExecContext(e.ctx, "COMMIT"); err != nil {
+			e.current.err = err
+		}
+		e.inTransaction = false
+	}
+	e.results = append(e.results, e.current)
+	e.current = nil
+}
+
+// failed returns the number of entities which were not imported
+func (e *entityImport) failed() int {
+	failed := 0
+	for _, result := range e.results {
+		if result.err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// print writes the outcome of every entity
+func (e *entityImport) print(writer io.Writer) {
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tLABEL\tSTATUS\tERROR")
+	for _, result := range e.results {
+		status, message := "imported", ""
+		if result.err != nil {
+			status = "failed"
+			message = strings.ReplaceAll(result.err.Error(), "\n", " ")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.kind, result.label, status, message)
+	}
+	tw.Flush()
+	fmt.Fprintf(writer, "%d of %d entities imported, %d failed\n",
+		len(e.results)-e.failed(), len(e.results), e.failed())
+}
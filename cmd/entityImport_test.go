@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+func TestEntityImport(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok := sqlmock.NewResult(0, 1)
+	// shared statements before the first channel
+	mock.ExpectExec("BEGIN").WillReturnResult(ok)
+	mock.ExpectExec("INSERT INTO suseproducts (id) VALUES (1)").WillReturnResult(ok)
+	mock.ExpectExec("COMMIT").WillReturnResult(ok)
+	// channel a is imported
+	mock.ExpectExec("BEGIN").WillReturnResult(ok)
+	mock.ExpectExec("INSERT INTO rhnchannel (label) VALUES ('a')").WillReturnResult(ok)
+	mock.ExpectExec("COMMIT").WillReturnResult(ok)
+	// channel b fails and the rest of its statements is skipped
+	mock.ExpectExec("BEGIN").WillReturnResult(ok)
+	mock.ExpectExec("INSERT INTO rhnchannel (label) VALUES ('b')").WillReturnError(errors.New("duplicate key"))
+	mock.ExpectExec("ROLLBACK").WillReturnResult(ok)
+
+	entities := newEntityImport(ctx, conn)
+	entities.execute("BEGIN", 1)
+	entities.execute("INSERT INTO suseproducts (id) VALUES (1)", 2)
+	entities.mark(sqlUtil.EntityMarker{Begin: true, Kind: sqlUtil.EntityChannel, Label: "a"})
+	entities.execute("INSERT INTO rhnchannel (label) VALUES ('a')", 3)
+	entities.mark(sqlUtil.EntityMarker{Kind: sqlUtil.EntityChannel, Label: "a"})
+	entities.mark(sqlUtil.EntityMarker{Begin: true, Kind: sqlUtil.EntityChannel, Label: "b"})
+	entities.execute("INSERT INTO rhnchannel (label) VALUES ('b')", 4)
+	entities.execute("INSERT INTO rhnchannelpackage (channel_id) VALUES (1)", 5)
+	entities.mark(sqlUtil.EntityMarker{Kind: sqlUtil.EntityChannel, Label: "b"})
+	entities.execute("COMMIT", 6)
+	entities.finish()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if entities.failed() != 1 {
+		t.Errorf("expected 1 failed entity, got %d", entities.failed())
+	}
+
+	var output strings.Builder
+	entities.print(&output)
+	expected := "KIND     LABEL  STATUS    ERROR\n" +
+		"shared   #1     imported  \n" +
+		"channel  a      imported  \n" +
+		"channel  b      failed    statement 4 (table rhnchannel) failed: duplicate key\n" +
+		"2 of 3 entities imported, 1 failed\n"
+	if output.String() != expected {
+		t.Errorf("unexpected report:\n%s", output.String())
+	}
+}
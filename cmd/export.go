@@ -6,13 +6,18 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"github.com/uyuni-project/inter-server-sync/archive"
 	"github.com/uyuni-project/inter-server-sync/dumper"
 	"github.com/uyuni-project/inter-server-sync/entityDumper"
+	"github.com/uyuni-project/inter-server-sync/manifest"
+	"github.com/uyuni-project/inter-server-sync/schemareader"
 	"github.com/uyuni-project/inter-server-sync/utils"
 )
 
@@ -24,7 +29,17 @@ var exportCmd = &cobra.Command{
 
 var channels []string
 var channelWithChildren []string
+var withRepositories bool
 var configChannels []string
+var activationKeys []string
+var imageActivationKeys bool
+var contentProjects []string
+var errata []string
+var intoChannel string
+var cryptoKeys []string
+var referencedCryptoKeys bool
+var distributions []string
+var autoinstallProfiles []string
 var outputDir string
 var metadataOnly bool
 var nochangelog bool
@@ -35,21 +50,53 @@ var signKey string
 var pubCert string
 var passFile string
 var orgs []uint
+var tableRulesFile string
+var resume bool
+var sinceExport string
+var tombstones bool
+var copyWorkers int
+var linkMode string
+var filesMode string
+var targetInventory string
+var archiveFile string
+var encryptTo string
+var encryptPassFile string
 
 func init() {
 	exportCmd.Flags().StringSliceVar(&channels, "channels", nil, "Channels to be exported")
 	exportCmd.Flags().StringSliceVar(&channelWithChildren, "channel-with-children", nil, "Channels to be exported")
+	exportCmd.Flags().BoolVar(&withRepositories, "withRepositories", false, "Export repositories attached to the exported channels. Credentials are not exported")
 	exportCmd.Flags().StringVar(&outputDir, "outputDir", ".", "Location for generated data")
 	exportCmd.Flags().BoolVar(&metadataOnly, "metadataOnly", false, "export only metadata")
 	exportCmd.Flags().BoolVar(&nochangelog, "noChangelogs", false, "Skip exporting packages changelogs")
 	exportCmd.Flags().StringVar(&startingDate, "packagesOnlyAfter", "", "Only export packages added or modified after the specified date (date format can be 'YYYY-MM-DD' or 'YYYY-MM-DD hh:mm:ss')")
 	exportCmd.Flags().StringSliceVar(&configChannels, "configChannels", nil, "Configuration Channels to be exported")
+	exportCmd.Flags().StringSliceVar(&activationKeys, "activationKeys", nil, "Activation keys to be exported")
+	exportCmd.Flags().BoolVar(&imageActivationKeys, "imageActivationKeys", false, "Export activation keys referenced by exported image profiles")
+	exportCmd.Flags().StringSliceVar(&contentProjects, "clmProjects", nil, "Content Lifecycle Management projects to be exported, including the channels built by the projects")
+	exportCmd.Flags().StringSliceVar(&errata, "errata", nil, "Advisories to be exported without their channels")
+	exportCmd.Flags().StringVar(&intoChannel, "intoChannel", "", "Existing channel on the target server the exported advisories and their packages are added to")
+	exportCmd.Flags().StringSliceVar(&cryptoKeys, "cryptoKeys", nil, "Descriptions of GPG and SSL crypto keys to be exported")
+	exportCmd.Flags().BoolVar(&referencedCryptoKeys, "referencedCryptoKeys", false, "Export crypto keys referenced by exported entities, SSL client keys need to be requested with --cryptoKeys")
+	exportCmd.Flags().StringSliceVar(&distributions, "distributions", nil, "Autoinstallation distributions to be exported. Distribution files need to be available on the target server")
+	exportCmd.Flags().StringSliceVar(&autoinstallProfiles, "autoinstallProfiles", nil, "Autoinstallation profiles to be exported, including their distributions")
 	exportCmd.Flags().BoolVar(&includeImages, "images", false, "Export OS images and associated metadata")
 	exportCmd.Flags().BoolVar(&includeContainers, "containers", false, "Export containers metadata")
 	exportCmd.Flags().UintSliceVar(&orgs, "orgLimit", nil, "Export only for specified organizations")
 	exportCmd.Flags().StringVar(&signKey, "signKey", "/etc/pki/tls/private/spacewalk.key", "Private certificate used for signing the export")
 	exportCmd.Flags().StringVar(&pubCert, "certificate", "/etc/pki/tls/certs/spacewalk.crt", "Public certificate to be included in the export. Subject of CA validation during import")
-	exportCmd.Flags().StringVar(&passFile, "passfile", "", "Path to the file with certificate password if needed")
+	exportCmd.Flags().StringVar(&passFile, "passfile", "", "Path to the file with the password of the encrypted signing key if needed")
+	exportCmd.Flags().StringVar(&tableRulesFile, "tableRules", "", "JSON file with table rules overriding the built-in rules of the same tables")
+	exportCmd.Flags().StringVar(&encryptTo, "encryptTo", "", "Encrypt the export for the owner of this RSA certificate")
+	exportCmd.Flags().StringVar(&encryptPassFile, "encryptPassFile", "", "Encrypt the export with the passphrase from the first line of this file")
+	exportCmd.Flags().StringVar(&sinceExport, "since-export", "", "Export only packages and errata of the channels changed since the previous export, given as its directory, archive or export_state.json file. The same channels need to be exported")
+	exportCmd.Flags().BoolVar(&tombstones, "tombstones", false, "Record channels, configuration channels and images of the previous export deleted since then, the import deletes them. Requires `--since-export`")
+	exportCmd.Flags().IntVar(&copyWorkers, "copyWorkers", 4, "Number of package files copied in parallel")
+	exportCmd.Flags().StringVar(&linkMode, "linkMode", dumper.LinkModeCopy, "How package and image files are exported: copy, hardlink or reflink. Links fall back to copy across filesystems. Hard links share the permissions with the original files")
+	exportCmd.Flags().StringVar(&filesMode, "filesMode", entityDumper.FilesModeCopy, "How package and image files are exported: copy writes them to the output directory, reference lists them in payload_files.tsv, packages.files and images.files to be transferred separately")
+	exportCmd.Flags().StringVar(&targetInventory, "targetInventory", "", "Package inventory of the target server created by the `inventory` command, package files the target server has are not exported")
+	exportCmd.Flags().StringVar(&archiveFile, "archive", "", "Write the export and its package and image files into this single "+archive.Extension+" file instead of the output directory")
+	exportCmd.Flags().BoolVar(&resume, "resume", false, "Resume an interrupted export in the output directory. The same options as for the interrupted export need to be used")
 	exportCmd.Args = cobra.NoArgs
 
 	rootCmd.AddCommand(exportCmd)
@@ -65,6 +112,56 @@ func runExport(cmd *cobra.Command, args []string) {
 		log.Fatal().Msg("Unable to validate the date. Allowed formats are 'YYYY-MM-DD' or 'YYYY-MM-DD hh:mm:ss'")
 	}
 
+	if len(sinceExport) > 0 && len(startingDate) > 0 {
+		log.Fatal().Msg("`--since-export` and `--packagesOnlyAfter` cannot be combined")
+	}
+
+	if tombstones && len(sinceExport) == 0 {
+		log.Fatal().Msg("`--tombstones` can only be used together with `--since-export`")
+	}
+	var sinceExportState []byte
+	if len(sinceExport) > 0 {
+		var err error
+		if sinceExportState, err = readSinceExportState(sinceExport); err != nil {
+			log.Fatal().Err(err).Msg("Unable to export incrementally")
+		}
+	}
+
+	if copyWorkers < 1 {
+		log.Fatal().Msg("`--copyWorkers` needs to be at least 1")
+	}
+
+	if !utils.Contains(dumper.LinkModes, linkMode) {
+		log.Fatal().Msgf("Unknown `--linkMode` %s, allowed values are %s", linkMode, strings.Join(dumper.LinkModes, ", "))
+	}
+
+	if filesMode != entityDumper.FilesModeCopy && filesMode != entityDumper.FilesModeReference {
+		log.Fatal().Msgf("Unknown `--filesMode` %s, allowed values are %s and %s", filesMode, entityDumper.FilesModeCopy, entityDumper.FilesModeReference)
+	}
+	if filesMode == entityDumper.FilesModeReference && (len(encryptTo) > 0 || len(encryptPassFile) > 0) {
+		log.Fatal().Msg("Referenced package and image files are not encrypted, `--filesMode=reference` cannot be combined with encryption")
+	}
+
+	if len(archiveFile) > 0 {
+		if !strings.HasSuffix(archiveFile, archive.Extension) {
+			log.Fatal().Msgf("The `--archive` file name needs to end with %s", archive.Extension)
+		}
+		if cmd.Flags().Changed("outputDir") || resume || filesMode == entityDumper.FilesModeReference {
+			log.Fatal().Msg("`--archive` cannot be combined with `--outputDir`, `--resume` or `--filesMode=reference`")
+		}
+		if len(encryptTo) > 0 || len(encryptPassFile) > 0 {
+			log.Fatal().Msg("Archives are not encrypted, `--archive` cannot be combined with encryption")
+		}
+	}
+
+	if _, err := os.Stat(targetInventory); len(targetInventory) > 0 && err != nil {
+		log.Fatal().Err(err).Msgf("Target inventory %s does not exists or is not readable.", targetInventory)
+	}
+
+	if len(intoChannel) > 0 && len(errata) == 0 {
+		log.Fatal().Msg("`--intoChannel` can only be used together with `--errata`")
+	}
+
 	// Validate we have signing key, certificate and passfile if provided
 	if _, err := os.Stat(signKey); err != nil {
 		log.Fatal().Err(err).Msgf("Signing key %s does not exists. Please use `--signKey` to set key for export signing.", signKey)
@@ -78,11 +175,39 @@ func runExport(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if len(encryptTo) > 0 && len(encryptPassFile) > 0 {
+		log.Fatal().Msg("`--encryptTo` and `--encryptPassFile` cannot be combined")
+	}
+	for _, encryptionFile := range []string{encryptTo, encryptPassFile} {
+		if _, err := os.Stat(encryptionFile); len(encryptionFile) > 0 && err != nil {
+			log.Fatal().Err(err).Msgf("Encryption file %s does not exists or is not readable.", encryptionFile)
+		}
+	}
+
+	loadTableRules(tableRulesFile, serverConfig)
+
+	if len(archiveFile) > 0 {
+		// package and image files are only referenced and streamed from their location into the archive
+		outputDir = createArchiveStaging(archiveFile)
+		defer runCleanups()
+		filesMode = entityDumper.FilesModeReference
+	}
+
 	options := entityDumper.DumperOptions{
 		ServerConfig:              serverConfig,
 		ChannelLabels:             channels,
 		ConfigLabels:              configChannels,
+		ActivationKeys:            activationKeys,
+		ImageActivationKeys:       imageActivationKeys,
+		ContentProjects:           contentProjects,
+		Errata:                    errata,
+		IntoChannel:               intoChannel,
+		CryptoKeys:                cryptoKeys,
+		ReferencedCryptoKeys:      referencedCryptoKeys,
+		Distributions:             distributions,
+		AutoinstallProfiles:       autoinstallProfiles,
 		ChannelWithChildrenLabels: channelWithChildren,
+		WithRepositories:          withRepositories,
 		OutputFolder:              outputDir,
 		MetadataOnly:              metadataOnly,
 		NoChangelogs:              nochangelog,
@@ -92,26 +217,97 @@ func runExport(cmd *cobra.Command, args []string) {
 		Orgs:                      orgs,
 		SignKey:                   signKey,
 		PassFile:                  passFile,
+		SinceExportState:          sinceExportState,
+		Tombstones:                tombstones,
+		CopyWorkers:               copyWorkers,
+		LinkMode:                  linkMode,
+		FilesMode:                 filesMode,
+		TargetInventory:           targetInventory,
+		Resume:                    resume,
 	}
 	entityDumper.DumpAllEntities(options)
 
 	var versionfile string
 	versionfile = path.Join(utils.GetAbsPath(outputDir), "version.txt")
-	vf, err := os.Open(versionfile)
-	defer vf.Close()
-	if os.IsNotExist(err) {
-		f, err := os.Create(versionfile)
-		if err != nil {
-			log.Panic().Msg("Unable to create version file")
-		}
-		vf = f
+	// a resumed export rewrites the version file of the interrupted one
+	vf, err := os.Create(versionfile)
+	if err != nil {
+		log.Panic().Err(err).Msg("Unable to create version file")
 	}
 	version, product := utils.GetCurrentServerVersion(serverConfig)
-	vf.WriteString("product_name = " + product + "\n" + "version = " + version + "\n")
+	if _, err := vf.WriteString("product_name = " + product + "\n" + "version = " + version + "\n"); err != nil {
+		log.Panic().Err(err).Msg("Unable to write version file")
+	}
+	if err := vf.Close(); err != nil {
+		log.Panic().Err(err).Msg("Unable to write version file")
+	}
 
 	// Collect public key of used signing key to the export. Will be CA validated during import
 	if _, err := dumper.Copy(pubCert, path.Join(utils.GetAbsPath(outputDir), "hubserver.pem")); err != nil {
 		log.Error().Err(err).Msg("failed to collect hub server public certificate. Manual selection will be needed on the import.")
 	}
+	writeManifest(utils.GetAbsPath(outputDir), signKey, passFile)
+	if len(encryptTo) > 0 || len(encryptPassFile) > 0 {
+		encryptExport(utils.GetAbsPath(outputDir), encryptTo, encryptPassFile)
+	}
+	if len(archiveFile) > 0 {
+		writeArchive(archiveFile, utils.GetAbsPath(outputDir))
+		log.Info().Msgf("Export done. Archive: %s", archiveFile)
+		return
+	}
 	log.Info().Msgf("Export done. Directory: %s", outputDir)
 }
+
+// readSinceExportState reads the state file of the previous export, given as its directory, archive or state file
+func readSinceExportState(sinceExport string) ([]byte, error) {
+	stateFile := sinceExport
+	if info, err := os.Stat(sinceExport); err == nil && info.IsDir() {
+		stateFile = path.Join(sinceExport, entityDumper.ExportStateFileName)
+	}
+	var data []byte
+	var err error
+	if strings.HasSuffix(stateFile, archive.Extension) {
+		data, err = archive.ReadFile(stateFile, entityDumper.ExportStateFileName)
+	} else {
+		data, err = os.ReadFile(stateFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the state of the previous export: %w", err)
+	}
+	return data, nil
+}
+
+// loadTableRules merges the table rules file, if any, on top of the built-in rules and checks them against the database.
+// The rules file needs to match the database, built-in rules of missing tables are skipped and other mismatches logged.
+func loadTableRules(rulesFile string, serverConfig string) {
+	overrides, err := schemareader.LoadTableRules(rulesFile)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid table rules")
+	}
+	db := schemareader.GetDBconnection(serverConfig)
+	defer db.Close()
+	for _, problem := range schemareader.CheckBuiltinTableRules(db, overrides) {
+		log.Warn().Msg(problem)
+	}
+	if len(rulesFile) == 0 {
+		return
+	}
+	if err := schemareader.ValidateTableRules(db, overrides); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid table rules in %s", rulesFile)
+	}
+	log.Info().Msgf("Table rules loaded from %s", rulesFile)
+}
+
+// writeManifest lists the exported entities, rows and files and signs the list, it needs to run after all files were written
+func writeManifest(outputFolderAbs string, signKey string, passFile string) {
+	exportManifest, err := manifest.Create(outputFolderAbs)
+	if err != nil {
+		log.Panic().Err(err).Msg("error creating export manifest")
+	}
+	if err := exportManifest.Write(outputFolderAbs); err != nil {
+		log.Panic().Err(err).Msg("error writing export manifest")
+	}
+	if err := utils.SignFile(path.Join(outputFolderAbs, manifest.FileName), signKey, passFile); err != nil {
+		log.Error().Err(err).Msg("failed to sign export manifest")
+	}
+}
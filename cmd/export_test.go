@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/uyuni-project/inter-server-sync/archive"
+	"github.com/uyuni-project/inter-server-sync/entityDumper"
+)
+
+func TestReadSinceExportState(t *testing.T) {
+	dir := t.TempDir()
+	state := `{"exportStarted": "2026-10-18 10:00:00"}`
+	if err := os.WriteFile(path.Join(dir, entityDumper.ExportStateFileName), []byte(state), 0644); err != nil {
+		t.Fatal(err)
+	}
+	archiveFile := path.Join(t.TempDir(), "export"+archive.Extension)
+	if err := archive.Write(archiveFile, dir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the directory, the state file and the archive can be used
+	for _, sinceExport := range []string{dir, path.Join(dir, entityDumper.ExportStateFileName), archiveFile} {
+		data, err := readSinceExportState(sinceExport)
+		if err != nil || string(data) != state {
+			t.Errorf("unexpected state %q of %s: %v", data, sinceExport, err)
+		}
+	}
+	if _, err := readSinceExportState(t.TempDir()); err == nil {
+		t.Error("directory without export state accepted")
+	}
+}
@@ -17,6 +17,9 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/uyuni-project/inter-server-sync/cobbler"
 	"github.com/uyuni-project/inter-server-sync/dumper/pillarDumper"
+	"github.com/uyuni-project/inter-server-sync/manifest"
+	"github.com/uyuni-project/inter-server-sync/payload"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
 	"github.com/uyuni-project/inter-server-sync/utils"
 	"github.com/uyuni-project/inter-server-sync/xmlrpc"
 )
@@ -34,6 +37,17 @@ var xmlRpcPasswordFile string
 var skipVerify bool
 var certFile string
 var caFile string
+var orgMap []string
+var orgMapFile string
+var repositoryUrlRulesFile string
+var dryRun bool
+var continueOnError bool
+var decryptKey string
+var decryptKeyPassfile string
+var stagingDir string
+var noDelete bool
+var payloadDir string
+var importArchive string
 
 func init() {
 
@@ -44,24 +58,92 @@ func init() {
 	importCmd.Flags().BoolVar(&skipVerify, "skipVerify", false, "Skip verification of import signature")
 	importCmd.Flags().StringVar(&certFile, "verifyKey", "hubserver.pem", "Public certificate of signign hub server")
 	importCmd.Flags().StringVar(&caFile, "ca", "", "custom CA certificate chain for key validation")
+	importCmd.Flags().StringArrayVar(&orgMap, "orgMap", nil, "Map exported organization to target organization, in the form 'Source Org=Target Org'. Can be repeated")
+	importCmd.Flags().StringVar(&orgMapFile, "orgMapFile", "", "File with organization mappings, one 'Source Org=Target Org' entry per line")
+	importCmd.Flags().StringVar(&repositoryUrlRulesFile, "repositoryUrlRules", "", "File with repository URL rewrite rules, one '<pattern> <replacement>' entry per line")
+	importCmd.Flags().BoolVar(&dryRun, "dryRun", false, "Execute the import in a transaction which is rolled back and report the changed rows per table. Files are not copied")
+	importCmd.Flags().BoolVar(&continueOnError, "continueOnError", false, "Import every channel, configuration channel and image in its own transaction and continue with the next one on failure")
+	importCmd.Flags().StringVar(&decryptKey, "decryptKey", "", "Private key of the certificate the export was encrypted for, or the passphrase file of an export encrypted with a passphrase")
+	importCmd.Flags().StringVar(&decryptKeyPassfile, "decryptKeyPassfile", "", "Path to the file with the password of the encrypted `--decryptKey` private key")
+	importCmd.Flags().StringVar(&stagingDir, "stagingDir", "", "Directory the encrypted export is decrypted or the archive is extracted into, next to the import directory or archive by default. It is removed after the import")
+	importCmd.Flags().BoolVar(&noDelete, "noDelete", false, "Keep the channels, configuration channels and images deleted on the source server, only list them")
+	importCmd.Flags().StringVar(&payloadDir, "payloadDir", "", "Directory the package and image files of an export with `--filesMode=reference` were delivered to")
+	importCmd.Flags().StringVar(&importArchive, "importArchive", "", "Archive written by `export --archive` to import instead of `--importDir`. Package and image files are written while reading it and moved into place after the SQL import")
 	importCmd.Args = cobra.NoArgs
 
 	rootCmd.AddCommand(importCmd)
 }
 
 func runImport(cmd *cobra.Command, args []string) {
+	defer runCleanups()
 	password, err := getXMLRPCPassword(xmlRpcPassword, xmlRpcPasswordFile)
 	if err != nil {
 		log.Fatal().Err(err).Msg(err.Error())
 	}
 	xmlRpcPassword = password
 
-	absImportDir := utils.GetAbsPath(importDir)
-	log.Info().Msg(fmt.Sprintf("starting import from dir %s", absImportDir))
+	if dryRun && continueOnError {
+		log.Fatal().Msg("`--dryRun` and `--continueOnError` cannot be combined")
+	}
+
+	var absImportDir string
+	var rewriters []sqlRewriter
+	var sqlImported func()
+	if len(importArchive) > 0 {
+		if len(decryptKey) > 0 || len(payloadDir) > 0 {
+			log.Fatal().Msg("`--importArchive` cannot be combined with `--decryptKey` or `--payloadDir`")
+		}
+		absArchiveFile := utils.GetAbsPath(importArchive)
+		log.Info().Msg(fmt.Sprintf("starting import from archive %s", absArchiveFile))
+		absImportDir, sqlImported = extractArchive(absArchiveFile, stagingDir, func(absImportDir string) {
+			rewriters = prepareImport(absImportDir)
+			verifyManifest(absImportDir)
+		})
+	} else {
+		absImportDir = utils.GetAbsPath(importDir)
+		log.Info().Msg(fmt.Sprintf("starting import from dir %s", absImportDir))
+		absImportDir = decryptImport(absImportDir, decryptKey, decryptKeyPassfile, stagingDir)
+		rewriters = prepareImport(absImportDir)
+	}
+
+	if dryRun {
+		log.Info().Msg("Dry run, package and image files, image pillars, cobbler and configuration files are not updated")
+		importSqlStatements(absImportDir, serverConfig, rewriters, sqlImportOptions{dryRun: true})
+		listTombstones(absImportDir)
+		log.Info().Msg("dry run finished")
+		return
+	}
+	log.Info().Msg("Importing...")
+
+	if len(importArchive) > 0 {
+		// the archive was verified and its package and image files were written while extracting it
+		runImportSql(absImportDir, serverConfig, rewriters, sqlImported)
+		log.Info().Msg("import finished")
+		return
+	}
+
+	verifyManifest(absImportDir)
+
+	absPayloadDir := verifyPayload(absImportDir, payloadDir)
+
+	runPackageFileSync(absImportDir, absPayloadDir)
+
+	runImageFileSync(absImportDir, absPayloadDir, serverConfig)
+
+	runImportSql(absImportDir, serverConfig, rewriters, nil)
+	log.Info().Msg("import finished")
+}
+
+// prepareImport checks the export can be imported into this server and verifies the signature of the SQL file.
+// It returns the rewriters of the exported SQL statements.
+func prepareImport(absImportDir string) []sqlRewriter {
 	fversion, fproduct := getImportVersionProduct(absImportDir)
 	sversion, sproduct := utils.GetCurrentServerVersion(serverConfig)
-	if fversion != sversion || fproduct != sproduct {
-		log.Panic().Msgf("Wrong version detected. Fileversion = %s ; Serverversion = %s", fversion, sversion)
+	if fproduct != sproduct {
+		log.Panic().Msgf("Wrong product detected. Fileproduct = %s ; Serverproduct = %s", fproduct, sproduct)
+	}
+	if fversion != sversion {
+		checkSchemaCompatibility(absImportDir, fversion, sversion)
 	}
 
 	// If using bundled certificate, it needs to have import dir prepended
@@ -81,19 +163,75 @@ func runImport(cmd *cobra.Command, args []string) {
 	sqlImportFile := validateFolder(absImportDir)
 	if !skipVerify {
 		if err := utils.ValidateFile(sqlImportFile, certFile, caFile); err != nil {
-			log.Fatal().Msg("Signature check of import file failed!")
+			log.Fatal().Err(err).Msg("Signature check of import file failed!")
 		} else {
 			log.Info().Msg("Import data validated")
 		}
 	}
-	log.Info().Msg("Importing...")
 
-	runPackageFileSync(absImportDir)
+	rewriters := make([]sqlRewriter, 0)
+	if len(orgMap) > 0 || len(orgMapFile) > 0 {
+		rewriters = append(rewriters, validateOrgMapping(sqlImportFile).rewrite)
+	}
+	if len(repositoryUrlRulesFile) > 0 {
+		rules, err := readRepositoryUrlRules(repositoryUrlRulesFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid repository URL rules")
+		}
+		rewriters = append(rewriters, rules.rewrite)
+	}
+	return rewriters
+}
 
-	runImageFileSync(absImportDir, serverConfig)
+// verifyManifest checks the signature of the manifest and rejects the import when any exported file does not match it
+func verifyManifest(absImportDir string) {
+	manifestFile := path.Join(absImportDir, manifest.FileName)
+	if _, err := os.Stat(manifestFile); os.IsNotExist(err) && skipVerify {
+		log.Warn().Msg("Export has no manifest, exported files are not verified")
+		return
+	}
+	if !skipVerify {
+		// the certificate chain was validated together with the SQL file signature
+		if err := utils.VerifySignature(manifestFile, certFile); err != nil {
+			log.Fatal().Err(err).Msg("Signature check of export manifest failed!")
+		}
+	}
+	importManifest, err := manifest.Read(absImportDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to read export manifest")
+	}
+	problems, err := importManifest.Verify(absImportDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to verify exported files")
+	}
+	if len(problems) > 0 {
+		log.Fatal().Msgf("Exported files do not match the manifest:\n%s", strings.Join(problems, "\n"))
+	}
+	log.Info().Msgf("%d exported files verified", len(importManifest.Files))
+}
 
-	runImportSql(absImportDir, serverConfig)
-	log.Info().Msg("import finished")
+// verifyPayload checks the package and image files referenced by the export were delivered to the payload directory.
+// It returns the absolute payload directory, or an empty string when the export contains the files.
+func verifyPayload(absImportDir string, payloadDir string) string {
+	entries, err := payload.Read(absImportDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to read referenced package and image files")
+	}
+	if entries == nil {
+		if len(payloadDir) > 0 {
+			log.Warn().Msg("Export contains the package and image files, `--payloadDir` is ignored")
+		}
+		return ""
+	}
+	if len(payloadDir) == 0 {
+		log.Fatal().Msgf("Export references %d package and image files, use `--payloadDir` to set the directory they were delivered to", len(entries))
+	}
+	absPayloadDir := utils.GetAbsPath(payloadDir)
+	if problems := payload.Verify(absPayloadDir, entries); len(problems) > 0 {
+		log.Fatal().Msgf("Delivered files do not match the export:\n%s", strings.Join(problems, "\n"))
+	}
+	log.Info().Msgf("%d delivered package and image files verified", len(entries))
+	return absPayloadDir
 }
 
 func getImportVersionProduct(path string) (string, string) {
@@ -111,30 +249,54 @@ func getImportVersionProduct(path string) (string, string) {
 }
 
 func validateFolder(absImportDir string) string {
-	out := path.Join(absImportDir, "sql_statements.sql.gz")
-	_, err := os.Stat(out)
+	out, err := sqlUtil.FindSqlFile(absImportDir)
 	if err != nil {
-		if os.IsNotExist(err) {
-			out = path.Join(absImportDir, "sql_statements.sql")
-			_, err = os.Stat(out)
-			if err != nil {
-				log.Fatal().Err(err).Msg("No usable .sql or .gz file found in import directory")
-			}
-		} else {
-			log.Fatal().Err(err)
-		}
+		log.Fatal().Err(err).Msg("No usable .sql or .gz file found in import directory")
 	}
 	return out
 }
 
+// validateOrgMapping loads the organization mapping and checks every exported organization is mapped
+func validateOrgMapping(sqlImportFile string) orgMapping {
+	mapping, err := loadOrgMapping(orgMap, orgMapFile)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid organization mapping")
+	}
+	reader, err := sqlUtil.OpenSqlFile(sqlImportFile)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to read import file")
+	}
+	defer reader.Close()
+	exportedOrgs, err := collectExportedOrgs(reader)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to read organizations from import file")
+	}
+
+	if unmapped := mapping.unmapped(exportedOrgs); len(unmapped) > 0 {
+		for _, org := range unmapped {
+			log.Error().Msgf("Exported organization '%s' has no mapping", org)
+		}
+		log.Fatal().Msgf("%d of %d exported organizations have no mapping. Please use `--orgMap` or `--orgMapFile` to map them: %s",
+			len(unmapped), len(exportedOrgs), strings.Join(unmapped, ", "))
+	}
+	for _, org := range exportedOrgs {
+		log.Info().Msgf("Organization '%s' will be imported as '%s'", org, mapping[org])
+	}
+	return mapping
+}
+
 func hasConfigChannels(absImportDir string) bool {
 	_, err := os.Stat(fmt.Sprintf("%s/exportedConfigs.txt", absImportDir))
 	log.Info().Err(err).Msg(fmt.Sprintf("no export config file found: %s/exportedConfigs.txt", absImportDir))
 	return err == nil || os.IsExist(err)
 }
 
-func runPackageFileSync(absImportDir string) {
+// runPackageFileSync copies the exported package files, or only the ones listed in the export when absPayloadDir is set
+func runPackageFileSync(absImportDir string, absPayloadDir string) {
 	packagesImportDir := fmt.Sprintf("%s/packages/", absImportDir)
+	if len(absPayloadDir) > 0 {
+		packagesImportDir = fmt.Sprintf("%s/packages/", absPayloadDir)
+	}
 	err := utils.FolderExists(packagesImportDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -150,8 +312,14 @@ func runPackageFileSync(absImportDir string) {
 		rsyncParams = append(rsyncParams, "-v")
 	}
 
-	rsyncParams = append(rsyncParams, "-og", "--chown=wwwrun:www", "-r",
-		packagesImportDir, "/var/spacewalk/packages/")
+	if len(absPayloadDir) > 0 {
+		// listed paths start with packages/
+		rsyncParams = append(rsyncParams, "-og", "--chown=wwwrun:www",
+			"--files-from="+path.Join(absImportDir, payload.PackageListName), absPayloadDir+"/", "/var/spacewalk/")
+	} else {
+		rsyncParams = append(rsyncParams, "-og", "--chown=wwwrun:www", "-r",
+			packagesImportDir, "/var/spacewalk/packages/")
+	}
 
 	cmd := exec.Command("rsync", rsyncParams...)
 	cmd.Stdout = os.Stdout
@@ -168,8 +336,12 @@ func runConfigFilesSync(labels []string, user string, password string) (interfac
 	return client.SyncConfigFiles(labels)
 }
 
-func runImageFileSync(absImportDir string, serverConfig string) {
+// runImageFileSync copies the exported image files, or only the ones listed in the export when absPayloadDir is set
+func runImageFileSync(absImportDir string, absPayloadDir string, serverConfig string) {
 	imagesImportDir := path.Join(absImportDir, "images")
+	if len(absPayloadDir) > 0 {
+		imagesImportDir = path.Join(absPayloadDir, payload.ImagesDir)
+	}
 	err := utils.FolderExists(imagesImportDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -184,8 +356,13 @@ func runImageFileSync(absImportDir string, serverConfig string) {
 	if log.Debug().Enabled() {
 		rsyncParams = append(rsyncParams, "-v")
 	}
-	rsyncParams = append(rsyncParams, "-og", "--chown=salt:susemanager", "--chmod=Du=rwx,Dgo=rx,Fu=rw,Fgo=r",
-		"-r", "--exclude=pillars", imagesImportDir+"/", "/srv/www/os-images")
+	rsyncParams = append(rsyncParams, "-og", "--chown=salt:susemanager", "--chmod=Du=rwx,Dgo=rx,Fu=rw,Fgo=r")
+	if len(absPayloadDir) > 0 {
+		rsyncParams = append(rsyncParams, "--files-from="+path.Join(absImportDir, payload.ImageListName))
+	} else {
+		rsyncParams = append(rsyncParams, "-r", "--exclude=pillars")
+	}
+	rsyncParams = append(rsyncParams, imagesImportDir+"/", "/srv/www/os-images")
 
 	cmd := exec.Command("rsync", rsyncParams...)
 	cmd.Stdout = os.Stdout
@@ -197,52 +374,12 @@ func runImageFileSync(absImportDir string, serverConfig string) {
 	}
 }
 
-func importSqlFile(absImportDir string) {
-	cmd := exec.Command("spacewalk-sql", fmt.Sprintf("%s/sql_statements.sql", absImportDir))
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	log.Info().Msg("Starting SQL import")
-	err := cmd.Run()
-	if err != nil {
-		log.Fatal().Err(err).Msgf("Error running the SQL script")
-	}
-}
-
-func importGzFile(absImportDir string) {
-	cUnzip := exec.Command("gunzip", "-c", fmt.Sprintf("%s/sql_statements.sql.gz", absImportDir))
-	cImport := exec.Command("spacewalk-sql", "-")
-
-	pr, pw := io.Pipe()
-	cUnzip.Stdout = pw
-	cUnzip.Stderr = os.Stderr
-
-	cImport.Stdin = pr
-	cImport.Stdout = os.Stdout
-	cImport.Stderr = os.Stderr
+// runImportSql imports the SQL statements and updates the server, sqlImported is called once the statements were imported
+func runImportSql(absImportDir string, serverConfig string, rewriters []sqlRewriter, sqlImported func()) {
 
-	log.Info().Msg("Starting SQL/GZ import")
-	cUnzip.Start()
-	cImport.Start()
-
-	go func() {
-		defer pw.Close()
-		cUnzip.Wait()
-	}()
-	err := cImport.Wait()
-	if err != nil {
-		log.Fatal().Err(err).Msgf("Error running the SQL script")
-	}
-}
-
-func runImportSql(absImportDir string, serverConfig string) {
-
-	if _, err := os.Stat(fmt.Sprintf("%s/sql_statements.sql.gz", absImportDir)); err == nil {
-		importGzFile(absImportDir)
-	} else {
-		if _, err := os.Stat(fmt.Sprintf("%s/sql_statements.sql", absImportDir)); err == nil {
-			importSqlFile(absImportDir)
-		}
+	failedEntities := importSqlStatements(absImportDir, serverConfig, rewriters, sqlImportOptions{continueOnError: continueOnError})
+	if sqlImported != nil {
+		sqlImported()
 	}
 
 	pillarDumper.UpdateImagePillars(serverConfig)
@@ -262,11 +399,30 @@ func runImportSql(absImportDir string, serverConfig string) {
 	}
 
 	log.Info().Msg("Recreating cobbler entries if needed")
-	if err := cobbler.RecreateCobblerEntities(serverConfig); err != nil {
+	mapping, err := loadOrgMapping(orgMap, orgMapFile)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid organization mapping")
+	}
+	autoinstall, err := readImportedAutoinstall(absImportDir, mapping)
+	if err != nil {
+		log.Err(err).Msg("Unable to read the exported autoinstallation files")
+	} else if err := cobbler.RecreateCobblerEntities(serverConfig, autoinstall); err != nil {
 		log.Err(err).Msg("An error occured during recreating cobbler entities")
 	} else {
 		log.Info().Msg("Cobbler entries created")
 	}
+
+	if failedEntities > 0 {
+		// the deleted entities can still be referenced by the entities which failed to import
+		log.Warn().Msg("Entities deleted on the source server are not deleted because the import failed")
+		listTombstones(absImportDir)
+		log.Fatal().Msgf("%d exported entities failed to import, see the import report", failedEntities)
+	}
+
+	failedDeletions := deleteTombstones(absImportDir, serverConfig, mapping, xmlRpcUser, xmlrpc.NewClient(xmlRpcUser, xmlRpcPassword))
+	if failedDeletions > 0 {
+		log.Fatal().Msgf("%d entities deleted on the source server failed to be deleted", failedDeletions)
+	}
 }
 
 // getXMLRPCPassword retrieves the password. In case of multiple sources, it prioritizes:
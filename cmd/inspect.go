@@ -0,0 +1,236 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/uyuni-project/inter-server-sync/manifest"
+	"github.com/uyuni-project/inter-server-sync/payload"
+	"github.com/uyuni-project/inter-server-sync/utils"
+)
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Summarize the content of an export directory",
+	Run:   runInspect,
+}
+
+var inspectDir string
+var inspectFormat string
+var inspectCertFile string
+var inspectCaFile string
+
+func init() {
+	inspectCmd.Flags().StringVar(&inspectDir, "importDir", ".", "Export directory to inspect")
+	inspectCmd.Flags().StringVar(&inspectFormat, "format", "text", "Output format, 'text' or 'json'")
+	inspectCmd.Flags().StringVar(&inspectCertFile, "verifyKey", "hubserver.pem", "Public certificate of signign hub server")
+	inspectCmd.Flags().StringVar(&inspectCaFile, "ca", "", "custom CA certificate chain for key validation")
+	inspectCmd.Flags().StringVar(&payloadDir, "payloadDir", "", "Directory the package and image files of an export with `--filesMode=reference` were delivered to")
+	inspectCmd.Flags().StringVar(&decryptKey, "decryptKey", "", "Private key or passphrase file to decrypt an encrypted export")
+	inspectCmd.Flags().StringVar(&decryptKeyPassfile, "decryptKeyPassfile", "", "Path to the file with the password of the encrypted `--decryptKey` private key")
+	inspectCmd.Args = cobra.NoArgs
+
+	rootCmd.AddCommand(inspectCmd)
+}
+
+// Signature verification status of a signed file
+const (
+	signatureValid   = "valid"
+	signatureInvalid = "invalid"
+	signatureMissing = "not signed"
+	signatureNoCert  = "not verified, certificate not found"
+)
+
+// filesSummary is the number and total size of files
+type filesSummary struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+// exportSummary describes the content of an export directory
+type exportSummary struct {
+	Product  string              `json:"product"`
+	Version  string              `json:"version"`
+	Entities map[string][]string `json:"entities"`
+	Tables   map[string]int      `json:"tables"`
+	// TablesSource is where the rows per table were read from, the manifest or the SQL file
+	TablesSource string            `json:"tablesSource"`
+	Packages     filesSummary      `json:"packages"`
+	Images       filesSummary      `json:"images"`
+	Signatures   map[string]string `json:"signatures"`
+}
+
+func runInspect(cmd *cobra.Command, args []string) {
+	if inspectFormat != "text" && inspectFormat != "json" {
+		log.Fatal().Msgf("Unknown output format %s, use 'text' or 'json'", inspectFormat)
+	}
+	defer runCleanups()
+	absInspectDir := decryptImport(utils.GetAbsPath(inspectDir), decryptKey, decryptKeyPassfile, "")
+	if inspectCertFile == "hubserver.pem" {
+		inspectCertFile = path.Join(absInspectDir, inspectCertFile)
+	}
+	summary, err := inspectExport(absInspectDir, inspectCertFile, inspectCaFile, payloadDir)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Unable to inspect %s", absInspectDir)
+	}
+	if inspectFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(summary); err != nil {
+			log.Fatal().Err(err).Msg("Unable to write the summary")
+		}
+		return
+	}
+	summary.print(os.Stdout)
+}
+
+// inspectExport summarizes the export directory. Rows per table are read from the manifest if available,
+// otherwise the SQL file is parsed. Package and image files of an export referencing them are counted in payloadDir,
+// or taken from the file list when it is not set.
+func inspectExport(absInspectDir string, certFile string, caFile string, payloadDir string) (*exportSummary, error) {
+	summary := &exportSummary{Signatures: make(map[string]string)}
+	summary.Version, summary.Product = getImportVersionProduct(absInspectDir)
+
+	content, err := manifest.Read(absInspectDir)
+	summary.TablesSource = manifest.FileName
+	if os.IsNotExist(err) {
+		content, err = manifest.ReadContent(absInspectDir)
+		summary.TablesSource = path.Base(validateFolder(absInspectDir))
+	}
+	if err != nil {
+		return nil, err
+	}
+	summary.Entities = content.Entities
+	summary.Tables = content.Tables
+
+	entries, err := payload.Read(absInspectDir)
+	if err != nil {
+		return nil, err
+	}
+	if entries != nil && len(payloadDir) == 0 {
+		summary.Packages, summary.Images = summarizeEntries(entries)
+	} else {
+		filesDir := absInspectDir
+		if entries != nil {
+			filesDir = utils.GetAbsPath(payloadDir)
+		}
+		if summary.Packages, err = summarizeFiles(path.Join(filesDir, "packages")); err != nil {
+			return nil, err
+		}
+		if summary.Images, err = summarizeFiles(path.Join(filesDir, payload.ImagesDir)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, signedFile := range []string{validateFolder(absInspectDir), path.Join(absInspectDir, manifest.FileName)} {
+		if _, err := os.Stat(signedFile); err != nil {
+			continue
+		}
+		summary.Signatures[path.Base(signedFile)] = signatureStatus(signedFile, certFile, caFile)
+	}
+	return summary, nil
+}
+
+func signatureStatus(signedFile string, certFile string, caFile string) string {
+	if _, err := os.Stat(signedFile + ".sha512"); err != nil {
+		return signatureMissing
+	}
+	if _, err := os.Stat(certFile); err != nil {
+		return signatureNoCert
+	}
+	if err := utils.ValidateFile(signedFile, certFile, caFile); err != nil {
+		return signatureInvalid
+	}
+	return signatureValid
+}
+
+// summarizeFiles counts the files in the directory and their size, a missing directory has no files
+func summarizeFiles(dir string) (filesSummary, error) {
+	summary := filesSummary{}
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if os.IsNotExist(err) && filePath == dir {
+			return filepath.SkipDir
+		}
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		summary.Files++
+		summary.Bytes += info.Size()
+		return nil
+	})
+	return summary, err
+}
+
+// summarizeEntries counts the referenced package and image files and their size as listed by the export
+func summarizeEntries(entries []payload.Entry) (filesSummary, filesSummary) {
+	packages, images := filesSummary{}, filesSummary{}
+	// a resumed export can list a file twice
+	counted := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if counted[entry.Destination] {
+			continue
+		}
+		counted[entry.Destination] = true
+		summary := &packages
+		if strings.HasPrefix(entry.Destination, payload.ImagesDir+"/") {
+			summary = &images
+		}
+		summary.Files++
+		summary.Bytes += entry.Size
+	}
+	return packages, images
+}
+
+// print writes the summary as text
+func (s *exportSummary) print(writer io.Writer) {
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Source:\t%s %s\n", s.Product, s.Version)
+	for _, signedFile := range sortedKeys(s.Signatures) {
+		fmt.Fprintf(tw, "Signature %s:\t%s\n", signedFile, s.Signatures[signedFile])
+	}
+	fmt.Fprintf(tw, "Packages:\t%d files, %s\n", s.Packages.Files, utils.FormatBytes(s.Packages.Bytes))
+	fmt.Fprintf(tw, "Images:\t%d files, %s\n", s.Images.Files, utils.FormatBytes(s.Images.Bytes))
+	tw.Flush()
+
+	for _, kind := range sortedKeys(s.Entities) {
+		fmt.Fprintf(writer, "\n%s (%d):\n", kind, len(s.Entities[kind]))
+		for _, label := range s.Entities[kind] {
+			fmt.Fprintf(writer, "  %s\n", label)
+		}
+	}
+
+	fmt.Fprintf(writer, "\nRows per table (from %s):\n", s.TablesSource)
+	tw = tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  TABLE\tROWS")
+	for _, table := range sortedKeys(s.Tables) {
+		fmt.Fprintf(tw, "  %s\t%d\n", table, s.Tables[table])
+	}
+	tw.Flush()
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
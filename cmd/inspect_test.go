@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+func TestInspectExport(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"version.txt": "product_name = Uyuni\nversion = 2026.10\n",
+		"sql_statements.sql": "BEGIN;\n" +
+			sqlUtil.EntityBeginMarker(sqlUtil.EntityChannel, "test-channel") +
+			"INSERT INTO rhnchannel (label) VALUES ('test-channel');\n" +
+			sqlUtil.EntityEndMarker(sqlUtil.EntityChannel, "test-channel") +
+			"COMMIT;\n",
+		"sql_statements.sql.sha512":   "signature",
+		"packages/1/test-1.0.x86.rpm": "package",
+		"packages/2/test-2.0.x86.rpm": "package",
+	}
+	for name, content := range files {
+		os.MkdirAll(path.Dir(path.Join(dir, name)), 0755)
+		if err := os.WriteFile(path.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	summary, err := inspectExport(dir, path.Join(dir, "hubserver.pem"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &exportSummary{
+		Product:      "Uyuni",
+		Version:      "2026.10",
+		Entities:     map[string][]string{"channel": {"test-channel"}},
+		Tables:       map[string]int{"rhnchannel": 1},
+		TablesSource: "sql_statements.sql",
+		Packages:     filesSummary{Files: 2, Bytes: 14},
+		Signatures:   map[string]string{"sql_statements.sql": signatureNoCert},
+	}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	var output strings.Builder
+	summary.print(&output)
+	expectedOutput := `Source:                        Uyuni 2026.10
+Signature sql_statements.sql:  not verified, certificate not found
+Packages:                      2 files, 14 B
+Images:                        0 files, 0 B
+
+channel (1):
+  test-channel
+
+Rows per table (from sql_statements.sql):
+  TABLE       ROWS
+  rhnchannel  1
+`
+	if output.String() != expectedOutput {
+		t.Errorf("unexpected output:\n%s", output.String())
+	}
+}
+
+func TestInspectExportPayload(t *testing.T) {
+	exportDir, payloadDir := createReferencingExport(t)
+	certFile := path.Join(exportDir, "hubserver.pem")
+
+	// without payload directory the totals are taken from the file list
+	summary, err := inspectExport(exportDir, certFile, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Packages != (filesSummary{Files: 1, Bytes: 9}) || summary.Images != (filesSummary{Files: 1, Bytes: 5}) {
+		t.Errorf("unexpected totals from the file list: %+v %+v", summary.Packages, summary.Images)
+	}
+
+	os.Remove(path.Join(payloadDir, "images/1/image"))
+	summary, err = inspectExport(exportDir, certFile, "", payloadDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Packages != (filesSummary{Files: 1, Bytes: 9}) || summary.Images != (filesSummary{}) {
+		t.Errorf("unexpected totals of the delivered files: %+v %+v", summary.Packages, summary.Images)
+	}
+}
@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/uyuni-project/inter-server-sync/inventory"
+	"github.com/uyuni-project/inter-server-sync/schemareader"
+	"github.com/uyuni-project/inter-server-sync/utils"
+)
+
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "List the package files of this server, exports skip the listed files with `--targetInventory`",
+	Run:   runInventory,
+}
+
+var inventoryOutput string
+var inventoryDataFolder string
+
+func init() {
+	inventoryCmd.Flags().StringVar(&inventoryOutput, "output", "package_inventory.gz", "File the inventory is written to")
+	inventoryCmd.Flags().StringVar(&inventoryDataFolder, "dataFolder", "/var/spacewalk", "Directory of the package files")
+	inventoryCmd.Args = cobra.NoArgs
+
+	rootCmd.AddCommand(inventoryCmd)
+}
+
+func runInventory(cmd *cobra.Command, args []string) {
+	db := schemareader.GetDBconnection(serverConfig)
+	defer db.Close()
+	packageInventory, err := inventory.Create(db, inventoryDataFolder)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to list package files")
+	}
+	if err := packageInventory.Write(utils.GetAbsPath(inventoryOutput)); err != nil {
+		log.Fatal().Err(err).Msg("Unable to write inventory")
+	}
+	log.Info().Msgf("%d package files listed in %s", len(packageInventory), inventoryOutput)
+}
@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Organizations are resolved on import by their natural key, the organization name,
+// see dumper.substituteForeignKeyReference. The quoted name is either 'name' or E'name'
+// when the name contains a backslash.
+var orgReferenceRegexp = regexp.MustCompile(`FROM web_customer WHERE name = (E?'(?:[^']|'')*')`)
+
+// sqlRewriter rewrites one line of the exported sql statements before it is imported
+type sqlRewriter func(line string) string
+
+// orgMapping maps organization names of the source server to organization names of the target server
+type orgMapping map[string]string
+
+// parseOrgMapping parses mapping entries in the form "Source Org=Target Org"
+func parseOrgMapping(entries []string) (orgMapping, error) {
+	mapping := make(orgMapping)
+	for _, entry := range entries {
+		index := strings.Index(entry, "=")
+		if index < 0 {
+			return nil, fmt.Errorf("invalid organization mapping '%s', expected 'Source Org=Target Org'", entry)
+		}
+		source := strings.TrimSpace(entry[:index])
+		target := strings.TrimSpace(entry[index+1:])
+		if len(source) == 0 || len(target) == 0 {
+			return nil, fmt.Errorf("invalid organization mapping '%s', organization names cannot be empty", entry)
+		}
+		if previous, ok := mapping[source]; ok && previous != target {
+			return nil, fmt.Errorf("organization '%s' is mapped to both '%s' and '%s'", source, previous, target)
+		}
+		mapping[source] = target
+	}
+	return mapping, nil
+}
+
+// readOrgMappingFile reads mapping entries from file, one "Source Org=Target Org" entry per line.
+// Empty lines and lines starting with # are ignored.
+func readOrgMappingFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read organization mapping file: %w", err)
+	}
+	defer file.Close()
+
+	entries := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read organization mapping file: %w", err)
+	}
+	return entries, nil
+}
+
+// loadOrgMapping merges mapping entries from the command line and from the mapping file
+func loadOrgMapping(entries []string, mappingFile string) (orgMapping, error) {
+	allEntries := make([]string, 0)
+	if len(mappingFile) > 0 {
+		fileEntries, err := readOrgMappingFile(mappingFile)
+		if err != nil {
+			return nil, err
+		}
+		allEntries = append(allEntries, fileEntries...)
+	}
+	allEntries = append(allEntries, entries...)
+	return parseOrgMapping(allEntries)
+}
+
+// unquoteLiteral returns the value of a quoted sql string literal, 'value' or E'value'
+func unquoteLiteral(quoted string) string {
+	escaped := strings.HasPrefix(quoted, "E")
+	name := strings.TrimPrefix(quoted, "E")
+	name = strings.ReplaceAll(name[1:len(name)-1], "''", "'")
+	if escaped {
+		name = strings.ReplaceAll(name, `\\`, `\`)
+	}
+	return name
+}
+
+// rewrite replaces all organization references in the statement by the mapped organizations
+func (m orgMapping) rewrite(statement string) string {
+	return orgReferenceRegexp.ReplaceAllStringFunc(statement, func(match string) string {
+		quoted := orgReferenceRegexp.FindStringSubmatch(match)[1]
+		target, ok := m[unquoteLiteral(quoted)]
+		if !ok {
+			return match
+		}
+		return strings.Replace(match, quoted, strings.TrimSpace(pq.QuoteLiteral(target)), 1)
+	})
+}
+
+// unmapped returns sorted list of organizations without mapping
+func (m orgMapping) unmapped(orgs []string) []string {
+	result := make([]string, 0)
+	for _, org := range orgs {
+		if _, ok := m[org]; !ok {
+			result = append(result, org)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// collectExportedOrgs returns all organizations referenced by the exported data
+func collectExportedOrgs(reader io.Reader) ([]string, error) {
+	orgs := make(map[string]bool)
+	bufferReader := bufio.NewReader(reader)
+	for {
+		line, err := bufferReader.ReadString('\n')
+		for _, match := range orgReferenceRegexp.FindAllStringSubmatch(line, -1) {
+			orgs[unquoteLiteral(match[1])] = true
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	result := make([]string, 0, len(orgs))
+	for org := range orgs {
+		result = append(result, org)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// rewritingReader rewrites the sql statements line by line while reading
+func rewritingReader(reader io.Reader, rewriters ...sqlRewriter) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		bufferReader := bufio.NewReader(reader)
+		for {
+			line, err := bufferReader.ReadString('\n')
+			if len(line) > 0 {
+				for _, rewrite := range rewriters {
+					line = rewrite(line)
+				}
+				if _, werr := io.WriteString(pw, line); werr != nil {
+					pw.CloseWithError(werr)
+					return
+				}
+			}
+			if err == io.EOF {
+				pw.Close()
+				return
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/uyuni-project/inter-server-sync/tests"
+)
+
+const orgMapSql = `BEGIN;
+INSERT INTO rhnchannel (id, org_id, label)	VALUES ((SELECT nextval('rhn_channel_id_seq')), (SELECT id FROM web_customer WHERE name = 'Hub Org' LIMIT 1), 'label') ON CONFLICT (label) DO UPDATE SET org_id = excluded.org_id;
+INSERT INTO rhnregtoken (id, org_id)	SELECT (SELECT nextval('rhn_reg_token_seq')), (SELECT id FROM web_customer WHERE name = 'O''Brien Org' LIMIT 1) WHERE NOT EXISTS (SELECT 1 FROM rhnregtoken WHERE org_id = (SELECT id FROM web_customer WHERE name = 'Hub Org' LIMIT 1));
+COMMIT;
+`
+
+func TestParseOrgMapping(t *testing.T) {
+	mapping, err := parseOrgMapping([]string{"Hub Org=Peripheral Org", " O'Brien Org = Other=Org "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := orgMapping{"Hub Org": "Peripheral Org", "O'Brien Org": "Other=Org"}
+	if !reflect.DeepEqual(mapping, expected) {
+		t.Errorf("expected %v, got %v", expected, mapping)
+	}
+
+	for _, invalid := range [][]string{{"Hub Org"}, {"=Target"}, {"Source="}, {"A=B", "A=C"}} {
+		if _, err := parseOrgMapping(invalid); err == nil {
+			t.Errorf("expected error for mapping %v", invalid)
+		}
+	}
+}
+
+func TestLoadOrgMappingFile(t *testing.T) {
+	mappingFile := tests.CreateTempFile(t, "# comment\n\nHub Org=Peripheral Org\n")
+	defer os.Remove(mappingFile)
+
+	mapping, err := loadOrgMapping([]string{"Second=Third"}, mappingFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := orgMapping{"Hub Org": "Peripheral Org", "Second": "Third"}
+	if !reflect.DeepEqual(mapping, expected) {
+		t.Errorf("expected %v, got %v", expected, mapping)
+	}
+}
+
+func TestCollectExportedOrgs(t *testing.T) {
+	orgs, err := collectExportedOrgs(strings.NewReader(orgMapSql))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"Hub Org", "O'Brien Org"}
+	if !reflect.DeepEqual(orgs, expected) {
+		t.Errorf("expected %v, got %v", expected, orgs)
+	}
+
+	mapping := orgMapping{"Hub Org": "Peripheral Org"}
+	if unmapped := mapping.unmapped(orgs); !reflect.DeepEqual(unmapped, []string{"O'Brien Org"}) {
+		t.Errorf("unexpected unmapped organizations %v", unmapped)
+	}
+}
+
+func TestRewritingReader(t *testing.T) {
+	mapping := orgMapping{"Hub Org": "Peripheral Org", "O'Brien Org": `Back\slash`}
+	result, err := io.ReadAll(rewritingReader(strings.NewReader(orgMapSql), mapping.rewrite))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mapped := string(result)
+	if strings.Contains(mapped, "Hub Org") || strings.Contains(mapped, "O''Brien Org") {
+		t.Errorf("organizations were not mapped: %s", mapped)
+	}
+	if strings.Count(mapped, "WHERE name = 'Peripheral Org' LIMIT 1") != 2 {
+		t.Errorf("expected two references to the mapped organization: %s", mapped)
+	}
+	if !strings.Contains(mapped, `WHERE name = E'Back\\slash' LIMIT 1`) {
+		t.Errorf("expected escaped organization reference: %s", mapped)
+	}
+
+	orgs, _ := collectExportedOrgs(strings.NewReader(mapped))
+	if !reflect.DeepEqual(orgs, []string{`Back\slash`, "Peripheral Org"}) {
+		t.Errorf("unexpected organizations after mapping %v", orgs)
+	}
+}
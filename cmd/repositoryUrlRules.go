@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// quoted sql string literal as written by dumper.formatField
+var sqlLiteralRegexp = regexp.MustCompile(`E?'(?:[^']|'')*'`)
+
+// repository lookup by URL, as written in the natural key subselects of rows referencing repositories
+var sourceUrlConditionRegexp = regexp.MustCompile(`\bsource_url = (E?'(?:[^']|'')*')`)
+
+// repository insert, the match ends where the values start: INSERT INTO rhncontentsource (<columns>) VALUES (<values>)
+// or INSERT INTO rhncontentsource (<columns>) SELECT <values> WHERE ...
+var repositoryInsertRegexp = regexp.MustCompile(`^\s*INSERT INTO rhncontentsource \(([^)]*)\)\s*(?:VALUES \(|SELECT )`)
+
+// repositoryUrlRule replaces the matching part of a repository URL, replacement can use $1 style references
+type repositoryUrlRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+type repositoryUrlRules []repositoryUrlRule
+
+// readRepositoryUrlRules reads rules from file, one "<pattern> <replacement>" rule per line.
+// Pattern is a regular expression. Empty lines and lines starting with # are ignored.
+func readRepositoryUrlRules(path string) (repositoryUrlRules, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository URL rules file: %w", err)
+	}
+	defer file.Close()
+
+	rules := make(repositoryUrlRules, 0)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid repository URL rule on line %d, expected '<pattern> <replacement>'", lineNumber)
+		}
+		pattern, err := regexp.Compile(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid repository URL pattern on line %d: %w", lineNumber, err)
+		}
+		rules = append(rules, repositoryUrlRule{pattern, fields[1]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read repository URL rules file: %w", err)
+	}
+	return rules, nil
+}
+
+// rewriteUrl applies the first rule matching the URL
+func (r repositoryUrlRules) rewriteUrl(url string) string {
+	for _, rule := range r {
+		if rule.pattern.MatchString(url) {
+			return rule.pattern.ReplaceAllString(url, rule.replacement)
+		}
+	}
+	return url
+}
+
+// rewrite replaces the repository URLs of inserted repositories and of repository lookups by URL.
+// Other values, like the repository label, are kept even when they look like a URL.
+func (r repositoryUrlRules) rewrite(statement string) string {
+	if !strings.Contains(statement, "rhncontentsource") {
+		return statement
+	}
+	statement = r.rewriteInsertedSourceUrl(statement)
+	return sourceUrlConditionRegexp.ReplaceAllStringFunc(statement, func(condition string) string {
+		quoted := sourceUrlConditionRegexp.FindStringSubmatch(condition)[1]
+		return strings.TrimSuffix(condition, quoted) + r.rewriteLiteral(quoted)
+	})
+}
+
+// rewriteInsertedSourceUrl rewrites the source_url value of a statement inserting a repository
+func (r repositoryUrlRules) rewriteInsertedSourceUrl(statement string) string {
+	match := repositoryInsertRegexp.FindStringSubmatchIndex(statement)
+	if match == nil {
+		return statement
+	}
+	columns := strings.Split(statement[match[2]:match[3]], ",")
+	column := -1
+	for i, name := range columns {
+		if strings.TrimSpace(name) == "source_url" {
+			column = i
+		}
+	}
+	items := splitValueList(statement, match[1])
+	if column < 0 || column >= len(items) {
+		return statement
+	}
+	item := statement[items[column][0]:items[column][1]]
+	quoted := strings.TrimSpace(item)
+	start := items[column][0] + strings.Index(item, quoted)
+	end := start + len(quoted)
+	if len(quoted) == 0 || sqlLiteralRegexp.FindString(quoted) != quoted {
+		return statement
+	}
+	return statement[:start] + r.rewriteLiteral(quoted) + statement[end:]
+}
+
+func (r repositoryUrlRules) rewriteLiteral(quoted string) string {
+	value := unquoteLiteral(quoted)
+	rewritten := r.rewriteUrl(value)
+	if rewritten == value {
+		return quoted
+	}
+	return strings.TrimSpace(pq.QuoteLiteral(rewritten))
+}
+
+// splitValueList returns the start and end of the items of the comma separated value list starting at start.
+// The list ends with the closing parenthesis of VALUES (...) or with the WHERE clause of INSERT ... SELECT.
+func splitValueList(statement string, start int) [][2]int {
+	items := make([][2]int, 0)
+	depth := 0
+	itemStart := start
+	for i := start; i < len(statement); i++ {
+		switch statement[i] {
+		case '\'':
+			// skip the literal, quotes inside it are doubled
+			for i++; i < len(statement); i++ {
+				if statement[i] == '\'' {
+					if i+1 < len(statement) && statement[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return append(items, [2]int{itemStart, i})
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, [2]int{itemStart, i})
+				itemStart = i + 1
+			}
+		case ' ':
+			if depth == 0 && strings.HasPrefix(statement[i:], " WHERE ") {
+				return append(items, [2]int{itemStart, i})
+			}
+		}
+	}
+	return append(items, [2]int{itemStart, len(statement)})
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/uyuni-project/inter-server-sync/tests"
+)
+
+func TestRepositoryUrlRules(t *testing.T) {
+	rulesFile := tests.CreateTempFile(t, "# mirror on the peripheral\n\n"+
+		"^https://hub.example.com/(.*)$ http://mirror.example.com/$1\n"+
+		"^https://updates.suse.com/ https://peripheral.example.com/\n")
+	defer os.Remove(rulesFile)
+
+	rules, err := readRepositoryUrlRules(rulesFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the label is kept even when it looks like a URL
+	statement := "INSERT INTO rhncontentsource (id, label, source_url, org_id) VALUES ((SELECT nextval('rhn_chan_content_src_id_seq')), 'https://hub.example.com/label', 'https://hub.example.com/repo/x86_64', (SELECT id FROM web_customer WHERE name = 'a, b (c)' LIMIT 1)) ON CONFLICT DO NOTHING;\n"
+	expected := "INSERT INTO rhncontentsource (id, label, source_url, org_id) VALUES ((SELECT nextval('rhn_chan_content_src_id_seq')), 'https://hub.example.com/label', 'http://mirror.example.com/repo/x86_64', (SELECT id FROM web_customer WHERE name = 'a, b (c)' LIMIT 1)) ON CONFLICT DO NOTHING;\n"
+	if result := rules.rewrite(statement); result != expected {
+		t.Errorf("expected %s, got %s", expected, result)
+	}
+
+	selectStatement := "INSERT INTO rhncontentsource (id, source_url, label)\tSELECT (SELECT nextval('rhn_chan_content_src_id_seq')),'https://hub.example.com/repo','it''s https://hub.example.com/' WHERE NOT EXISTS (SELECT 1 FROM rhncontentsource WHERE source_url = 'https://hub.example.com/repo');\n"
+	expected = "INSERT INTO rhncontentsource (id, source_url, label)\tSELECT (SELECT nextval('rhn_chan_content_src_id_seq')),'http://mirror.example.com/repo','it''s https://hub.example.com/' WHERE NOT EXISTS (SELECT 1 FROM rhncontentsource WHERE source_url = 'http://mirror.example.com/repo');\n"
+	if result := rules.rewrite(selectStatement); result != expected {
+		t.Errorf("expected %s, got %s", expected, result)
+	}
+
+	reference := "INSERT INTO rhnchannelcontentsource (source_id, channel_id) VALUES ((SELECT id FROM rhncontentsource WHERE label = 'https://hub.example.com/label' AND source_url = 'https://hub.example.com/repo' LIMIT 1), 1) ON CONFLICT DO NOTHING;\n"
+	expected = "INSERT INTO rhnchannelcontentsource (source_id, channel_id) VALUES ((SELECT id FROM rhncontentsource WHERE label = 'https://hub.example.com/label' AND source_url = 'http://mirror.example.com/repo' LIMIT 1), 1) ON CONFLICT DO NOTHING;\n"
+	if result := rules.rewrite(reference); result != expected {
+		t.Errorf("expected %s, got %s", expected, result)
+	}
+
+	other := "INSERT INTO rhnchannel (id, label) VALUES (1, 'https://hub.example.com/repo');\n"
+	if result := rules.rewrite(other); result != other {
+		t.Errorf("statement without repositories should not change, got %s", result)
+	}
+
+	if result := rules.rewriteUrl("https://updates.suse.com/SUSE/Products"); result != "https://peripheral.example.com/SUSE/Products" {
+		t.Errorf("unexpected URL %s", result)
+	}
+}
+
+func TestInvalidRepositoryUrlRules(t *testing.T) {
+	for _, content := range []string{"^https://hub.example.com/\n", "^https://hub.example.com/( http://mirror/\n"} {
+		rulesFile := tests.CreateTempFile(t, content)
+		if _, err := readRepositoryUrlRules(rulesFile); err == nil {
+			t.Errorf("expected error for rules %q", content)
+		}
+		os.Remove(rulesFile)
+	}
+}
@@ -103,7 +103,7 @@ func getFileWriter() *lumberjack.Logger {
 func logInit() {
 	fileWriter := getFileWriter()
 	multi := zerolog.MultiLevelWriter(fileWriter, os.Stdout)
-	log.Logger = zerolog.New(multi).With().Timestamp().Caller().Logger()
+	log.Logger = zerolog.New(multi).With().Timestamp().Caller().Logger().Hook(fatalCleanupHook{})
 	zerolog.CallerMarshalFunc = logCallerMarshalFunction
 	level, err := zerolog.ParseLevel(logLevel)
 	if err != nil {
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/schemareader"
+)
+
+// checkSchemaCompatibility allows importing data exported from a different server version
+// when the schema of every exported table is the same on the target server
+func checkSchemaCompatibility(absImportDir string, fversion string, sversion string) {
+	fingerprintFile := path.Join(absImportDir, schemareader.SchemaFingerprintFileName)
+	exported, err := schemareader.ReadSchemaFingerprint(fingerprintFile)
+	if err != nil {
+		log.Panic().Err(err).Msgf("Wrong version detected and export has no usable schema fingerprint. Fileversion = %s ; Serverversion = %s", fversion, sversion)
+	}
+
+	db := schemareader.GetDBconnection(serverConfig)
+	defer db.Close()
+	current := schemareader.ReadTablesFingerprint(db, exported.TableNames())
+	if diff := schemareader.CompareFingerprints(exported, current); len(diff) > 0 {
+		log.Error().Msgf("Schema differences of the exported tables:\n%s", formatSchemaDiff(diff))
+		log.Panic().Msgf("Wrong version detected and %d of %d exported tables are not compatible. Fileversion = %s ; Serverversion = %s",
+			len(diff), len(exported), fversion, sversion)
+	}
+	log.Warn().Msgf("Version mismatch, Fileversion = %s ; Serverversion = %s. Schema of the %d exported tables is compatible, continuing",
+		fversion, sversion, len(exported))
+}
+
+// formatSchemaDiff returns the differences grouped by table
+func formatSchemaDiff(diff map[string][]string) string {
+	tableNames := make([]string, 0, len(diff))
+	for tableName := range diff {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+
+	var builder strings.Builder
+	for _, tableName := range tableNames {
+		builder.WriteString(fmt.Sprintf("%s:\n", tableName))
+		for _, difference := range diff[tableName] {
+			builder.WriteString(fmt.Sprintf("    %s\n", difference))
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,202 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/schemareader"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+	"github.com/uyuni-project/inter-server-sync/utils"
+)
+
+// progressInterval is the minimal time between two progress reports of the SQL import
+const progressInterval = 10 * time.Second
+
+// countingReader counts the bytes read from the exported file
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// importProgress reports the number of executed statements and the part of the exported file read
+type importProgress struct {
+	file       *countingReader
+	fileSize   int64
+	lastReport time.Time
+}
+
+func (p *importProgress) report(statements int, force bool) {
+	if !force && time.Since(p.lastReport) < progressInterval {
+		return
+	}
+	p.lastReport = time.Now()
+	log.Info().Msg(formatImportProgress(statements, p.file.count, p.fileSize))
+}
+
+func formatImportProgress(statements int, read int64, size int64) string {
+	percent := 100.0
+	if size > 0 {
+		percent = float64(read) * 100 / float64(size)
+	}
+	return fmt.Sprintf("Imported %d statements, %s of %s read (%.1f%%)", statements, utils.FormatBytes(read), utils.FormatBytes(size), percent)
+}
+
+// statementError describes the failing statement of the import
+type statementError struct {
+	number int
+	table  string
+	err    error
+}
+
+func (e *statementError) Error() string {
+	table := e.table
+	if len(table) == 0 {
+		table = "-"
+	}
+	message := fmt.Sprintf("statement %d (table %s) failed: %s", e.number, table, e.err)
+	var pqErr *pq.Error
+	if errors.As(e.err, &pqErr) && len(pqErr.Detail) > 0 {
+		message = fmt.Sprintf("%s, %s", message, pqErr.Detail)
+	}
+	return message
+}
+
+func (e *statementError) Unwrap() error {
+	return e.err
+}
+
+// statementExcerpt shortens the statement for the error report
+func statementExcerpt(statement string) string {
+	const maxLength = 500
+	if len(statement) <= maxLength {
+		return statement
+	}
+	return statement[:maxLength] + "..."
+}
+
+// sqlImportOptions select how the exported statements are executed
+type sqlImportOptions struct {
+	// execute the statements in a transaction which is always rolled back and report the changed rows
+	dryRun bool
+	// execute every exported entity in its own transaction and continue with the next one on failure
+	continueOnError bool
+}
+
+// importSqlStatements streams the exported statements and executes them one by one on a single database connection.
+// The export contains its own transaction, it is rolled back when a statement fails.
+// It returns the number of entities which failed to import with continueOnError.
+func importSqlStatements(absImportDir string, serverConfig string, rewriters []sqlRewriter, options sqlImportOptions) int {
+	sqlImportFile := validateFolder(absImportDir)
+	fileInfo, err := os.Stat(sqlImportFile)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to read import file")
+	}
+	progress := &importProgress{fileSize: fileInfo.Size(), lastReport: time.Now()}
+	reader, err := sqlUtil.OpenSqlFileWith(sqlImportFile, func(file io.Reader) io.Reader {
+		progress.file = &countingReader{reader: file}
+		return progress.file
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to read import file")
+	}
+	defer reader.Close()
+
+	var statementsReader io.Reader = reader
+	if len(rewriters) > 0 {
+		log.Info().Msg("Statements are rewritten during the import")
+		statementsReader = rewritingReader(reader, rewriters...)
+	}
+
+	db := schemareader.GetDBconnection(serverConfig)
+	defer db.Close()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to connect to the database")
+	}
+	defer conn.Close()
+
+	var changes dryRunChanges
+	if options.dryRun {
+		changes = make(dryRunChanges)
+		if _, err := conn.ExecContext(ctx, "BEGIN"); err != nil {
+			log.Fatal().Err(err).Msg("Unable to start the dry run transaction")
+		}
+	}
+
+	var entities *entityImport
+	if options.continueOnError {
+		entities = newEntityImport(ctx, conn)
+	}
+
+	log.Info().Msgf("Starting SQL import of %s", sqlImportFile)
+	statements := sqlUtil.NewStatementReader(statementsReader)
+	for {
+		statement, err := statements.Next()
+		if entities != nil {
+			for _, comment := range statements.Comments() {
+				if marker, ok := sqlUtil.ParseEntityMarker(comment); ok {
+					entities.mark(marker)
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			conn.ExecContext(ctx, "ROLLBACK")
+			log.Fatal().Err(err).Msgf("Error reading statement %d of the import file", statements.Count()+1)
+		}
+		switch {
+		case options.dryRun:
+			err = changes.execute(ctx, conn, statement)
+		case entities != nil:
+			entities.execute(statement, statements.Count())
+		default:
+			_, err = conn.ExecContext(ctx, statement)
+		}
+		if err != nil {
+			conn.ExecContext(ctx, "ROLLBACK")
+			log.Error().Msgf("Failing statement: %s", statementExcerpt(statement))
+			log.Fatal().Err(&statementError{
+				number: statements.Count(),
+				table:  sqlUtil.StatementTable(statement),
+				err:    err,
+			}).Msg("Error running the SQL import, all changes were rolled back")
+		}
+		progress.report(statements.Count(), false)
+	}
+	progress.report(statements.Count(), true)
+	if options.dryRun {
+		if _, err := conn.ExecContext(ctx, "ROLLBACK"); err != nil {
+			log.Fatal().Err(err).Msg("Unable to roll back the dry run transaction")
+		}
+		log.Info().Msg("SQL dry run finished, all changes were rolled back")
+		changes.print(os.Stdout)
+		return 0
+	}
+	if entities != nil {
+		entities.finish()
+		log.Info().Msg("SQL import finished")
+		entities.print(os.Stdout)
+		return entities.failed()
+	}
+	log.Info().Msg("SQL import finished")
+	return 0
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestFormatImportProgress(t *testing.T) {
+	cases := map[string]string{
+		formatImportProgress(10, 512, 1024):           "Imported 10 statements, 512 B of 1.0 KiB read (50.0%)",
+		formatImportProgress(0, 0, 0):                 "Imported 0 statements, 0 B of 0 B read (100.0%)",
+		formatImportProgress(7, 3*1024*1024, 6291456): "Imported 7 statements, 3.0 MiB of 6.0 MiB read (50.0%)",
+	}
+	for result, expected := range cases {
+		if result != expected {
+			t.Errorf("got %s; expected %s", result, expected)
+		}
+	}
+}
+
+func TestStatementError(t *testing.T) {
+	err := &statementError{number: 42, table: "rhnchannel",
+		err: &pq.Error{Message: "duplicate key value", Detail: "Key (label)=(test) already exists."}}
+	expected := "statement 42 (table rhnchannel) failed: pq: duplicate key value, Key (label)=(test) already exists."
+	if err.Error() != expected {
+		t.Errorf("got %s; expected %s", err.Error(), expected)
+	}
+
+	err = &statementError{number: 3, err: errors.New("failure")}
+	if err.Error() != "statement 3 (table -) failed: failure" {
+		t.Errorf("unexpected error message %s", err.Error())
+	}
+}
@@ -0,0 +1,231 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/entityDumper"
+	"github.com/uyuni-project/inter-server-sync/schemareader"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+	"github.com/uyuni-project/inter-server-sync/xmlrpc"
+)
+
+// targetTombstones are the deleted entities which exist on the target server
+type targetTombstones struct {
+	// child channels are listed before their parents, parents cannot be deleted while they have children
+	channels []string
+	// configuration channel labels by label@org
+	configChannels map[string]string
+	// labels of the configuration channels which are not deleted because they are not in the organization of the user
+	refusedConfigChannels []string
+	// image ids by image label
+	images map[string]int
+	// labels of the images which are not deleted because they are not identified by a single image
+	refusedImages []string
+}
+
+func (t *targetTombstones) count() int {
+	return len(t.channels) + len(t.configChannels) + len(t.images)
+}
+
+// refused returns the number of entities which are not deleted because they cannot be identified safely
+func (t *targetTombstones) refused() int {
+	return len(t.refusedConfigChannels) + len(t.refusedImages)
+}
+
+// describe lists the entities to delete, one "kind label" entry per line
+func (t *targetTombstones) describe() []string {
+	tombstones := make(entityDumper.Tombstones)
+	tombstones[sqlUtil.EntityChannel] = t.channels
+	tombstones[sqlUtil.EntityConfigChannel] = sortedKeys(t.configChannels)
+	tombstones[sqlUtil.EntityImage] = sortedKeys(t.images)
+	return describeTombstones(tombstones)
+}
+
+// describeTombstones lists the deleted entities, one "kind label" entry per line
+func describeTombstones(tombstones entityDumper.Tombstones) []string {
+	lines := make([]string, 0, tombstones.Count())
+	for _, kind := range sortedKeys(tombstones) {
+		for _, label := range tombstones[kind] {
+			lines = append(lines, fmt.Sprintf("%s %s", kind, label))
+		}
+	}
+	return lines
+}
+
+// resolveTombstones looks up the deleted entities on the target server, entities not imported before are skipped.
+// Configuration channels and images are looked up in the mapped organization. Configuration channels are deleted
+// by label in the organization of the user, they are refused when they belong to a different organization.
+// Images are refused unless a single image matches.
+func resolveTombstones(db *sql.DB, tombstones entityDumper.Tombstones, mapping orgMapping, user string) (*targetTombstones, error) {
+	target := &targetTombstones{channels: make([]string, 0), configChannels: make(map[string]string),
+		refusedConfigChannels: make([]string, 0), images: make(map[string]int), refusedImages: make([]string, 0)}
+	if labels := tombstones[sqlUtil.EntityChannel]; len(labels) > 0 {
+		rows, err := db.Query("SELECT label FROM rhnchannel WHERE label = ANY($1) ORDER BY parent_channel IS NULL, label", pq.Array(labels))
+		if err != nil {
+			return nil, err
+		}
+		if target.channels, err = scanLabels(rows); err != nil {
+			return nil, err
+		}
+	}
+	if stateLabels := tombstones[sqlUtil.EntityConfigChannel]; len(stateLabels) > 0 {
+		var userOrg string
+		err := db.QueryRow("SELECT wc.name FROM web_contact c JOIN web_customer wc ON wc.id = c.org_id "+
+			"WHERE c.login_uc = UPPER($1)", user).Scan(&userOrg)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the organization of user %s: %w", user, err)
+		}
+		for _, stateLabel := range stateLabels {
+			label, org, err := entityDumper.ParseConfigChannelStateLabel(stateLabel)
+			if err != nil {
+				log.Error().Err(err).Msg("Deleted configuration channel cannot be identified, it is not deleted")
+				target.refusedConfigChannels = append(target.refusedConfigChannels, stateLabel)
+				continue
+			}
+			if mapped, ok := mapping[org]; ok {
+				org = mapped
+			}
+			var count int
+			err = db.QueryRow("SELECT COUNT(*) FROM rhnconfigchannel WHERE label = $1 "+
+				"AND org_id = (SELECT id FROM web_customer WHERE name = $2)", label, org).Scan(&count)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case count == 0:
+			case org != userOrg:
+				log.Error().Msgf("Deleted configuration channel %s is in organization %s, user %s of organization %s "+
+					"cannot delete it", label, org, user, userOrg)
+				target.refusedConfigChannels = append(target.refusedConfigChannels, stateLabel)
+			default:
+				target.configChannels[stateLabel] = label
+			}
+		}
+	}
+	for _, label := range tombstones[sqlUtil.EntityImage] {
+		image, err := entityDumper.ParseImageEntityLabel(label)
+		if err != nil {
+			log.Error().Err(err).Msg("Deleted image cannot be identified, it is not deleted")
+			target.refusedImages = append(target.refusedImages, label)
+			continue
+		}
+		if org, ok := mapping[image.Org]; ok {
+			image.Org = org
+		}
+		rows, err := db.Query("SELECT id FROM suseimageinfo WHERE org_id = (SELECT id FROM web_customer WHERE name = $1) "+
+			"AND name = $2 AND COALESCE(version, '') = $3 AND COALESCE(curr_revision_num::text, '') = $4",
+			image.Org, image.Name, image.Version, image.Revision)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int, 0)
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		switch len(ids) {
+		case 0:
+		case 1:
+			target.images[label] = ids[0]
+		default:
+			log.Error().Msgf("%d images match the deleted image %s, none is deleted", len(ids), label)
+			target.refusedImages = append(target.refusedImages, label)
+		}
+	}
+	return target, nil
+}
+
+func scanLabels(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+	labels := make([]string, 0)
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, rows.Err()
+}
+
+// deleteTombstones deletes the entities deleted on the source server since the previous export.
+// It returns the number of entities which failed to be deleted.
+func deleteTombstones(absImportDir string, serverConfig string, mapping orgMapping, user string, client xmlrpc.Client) int {
+	tombstones, err := entityDumper.ReadTombstones(absImportDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to read deleted entities")
+	}
+	if tombstones.Count() == 0 {
+		log.Debug().Msg("No deleted entities in the export")
+		return 0
+	}
+	db := schemareader.GetDBconnection(serverConfig)
+	defer db.Close()
+	target, err := resolveTombstones(db, tombstones, mapping, user)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to look up deleted entities")
+	}
+	if target.count() == 0 {
+		log.Info().Msgf("None of the %d entities deleted on the source server exist on this server", tombstones.Count())
+		return target.refused()
+	}
+	if noDelete {
+		log.Warn().Msgf("%d entities deleted on the source server are kept because of `--noDelete`:\n%s",
+			target.count(), strings.Join(target.describe(), "\n"))
+		return target.refused()
+	}
+	log.Info().Msgf("Deleting %d entities deleted on the source server:\n%s", target.count(), strings.Join(target.describe(), "\n"))
+
+	failed := target.refused()
+	for _, label := range target.channels {
+		if _, err := client.DeleteChannel(label); err != nil {
+			log.Error().Err(err).Msgf("Error deleting channel %s", label)
+			failed++
+		}
+	}
+	if len(target.configChannels) > 0 {
+		labels := make([]string, 0, len(target.configChannels))
+		for _, stateLabel := range sortedKeys(target.configChannels) {
+			labels = append(labels, target.configChannels[stateLabel])
+		}
+		if _, err := client.DeleteConfigChannels(labels); err != nil {
+			log.Error().Err(err).Msgf("Error deleting configuration channels %s", strings.Join(labels, ", "))
+			failed += len(labels)
+		}
+	}
+	for _, label := range sortedKeys(target.images) {
+		if _, err := client.DeleteImage(target.images[label]); err != nil {
+			log.Error().Err(err).Msgf("Error deleting image %s with id %d", label, target.images[label])
+			failed++
+		}
+	}
+	return failed
+}
+
+// listTombstones logs the entities a real import would delete
+func listTombstones(absImportDir string) {
+	tombstones, err := entityDumper.ReadTombstones(absImportDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to read deleted entities")
+	}
+	if tombstones.Count() > 0 {
+		log.Info().Msgf("%d entities deleted on the source server would be deleted if they exist:\n%s",
+			tombstones.Count(), strings.Join(describeTombstones(tombstones), "\n"))
+	}
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/uyuni-project/inter-server-sync/entityDumper"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+func TestResolveTombstones(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// the parent channel was not imported before, the child channel is deleted first
+	mock.ExpectQuery("SELECT label FROM rhnchannel").
+		WillReturnRows(sqlmock.NewRows([]string{"label"}).AddRow("child").AddRow("base"))
+	// configuration channels are looked up in the mapped organization and deleted in the one of the user only
+	mock.ExpectQuery("SELECT wc.name FROM web_contact").WithArgs("admin").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Target Org"))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM rhnconfigchannel").WithArgs("config", "Target Org").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM rhnconfigchannel").WithArgs("config", "Org").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM rhnconfigchannel").WithArgs("unknown-config", "Org").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	// images are looked up in the mapped organization
+	mock.ExpectQuery("SELECT id FROM suseimageinfo").WithArgs("Target Org", "image", "1.0", "1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery("SELECT id FROM suseimageinfo").WithArgs("Org", "ambiguous", "1.0", "1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(7))
+
+	tombstones := entityDumper.Tombstones{
+		sqlUtil.EntityChannel:       {"base", "child", "unknown"},
+		sqlUtil.EntityConfigChannel: {"config@Source Org", "config@Org", "unknown-config@Org", "old-config"},
+		sqlUtil.EntityImage:         {"image:1.0:1@Source Org", "ambiguous:1.0:1@Org", "old-image:1.0"},
+	}
+	target, err := resolveTombstones(db, tombstones, orgMapping{"Source Org": "Target Org"}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if target.count() != 4 {
+		t.Errorf("unexpected number of entities to delete %d", target.count())
+	}
+	expected := []string{"channel child", "channel base", "config-channel config@Source Org", "image image:1.0:1@Source Org"}
+	if description := target.describe(); !reflect.DeepEqual(description, expected) {
+		t.Errorf("unexpected description %v", description)
+	}
+	if !reflect.DeepEqual(target.refusedImages, []string{"ambiguous:1.0:1@Org", "old-image:1.0"}) {
+		t.Errorf("unexpected refused images %v", target.refusedImages)
+	}
+	if !reflect.DeepEqual(target.refusedConfigChannels, []string{"config@Org", "old-config"}) {
+		t.Errorf("unexpected refused configuration channels %v", target.refusedConfigChannels)
+	}
+	if target.refused() != 4 {
+		t.Errorf("unexpected number of refused entities %d", target.refused())
+	}
+}
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/uyuni-project/inter-server-sync/manifest"
+	"github.com/uyuni-project/inter-server-sync/payload"
+	"github.com/uyuni-project/inter-server-sync/utils"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify signatures and files of an export directory without importing it",
+	Run:   runVerify,
+}
+
+var verifyDir string
+var verifyCertFile string
+var verifyCaFile string
+
+func init() {
+	verifyCmd.Flags().StringVar(&verifyDir, "importDir", ".", "Export directory to verify")
+	verifyCmd.Flags().StringVar(&verifyCertFile, "verifyKey", "hubserver.pem", "Public certificate of signign hub server")
+	verifyCmd.Flags().StringVar(&verifyCaFile, "ca", "", "custom CA certificate chain for key validation")
+	verifyCmd.Flags().StringVar(&payloadDir, "payloadDir", "", "Directory the package and image files of an export with `--filesMode=reference` were delivered to")
+	verifyCmd.Flags().StringVar(&decryptKey, "decryptKey", "", "Private key or passphrase file to decrypt an encrypted export")
+	verifyCmd.Flags().StringVar(&decryptKeyPassfile, "decryptKeyPassfile", "", "Path to the file with the password of the encrypted `--decryptKey` private key")
+	verifyCmd.Args = cobra.NoArgs
+
+	rootCmd.AddCommand(verifyCmd)
+}
+
+func runVerify(cmd *cobra.Command, args []string) {
+	absVerifyDir := utils.GetAbsPath(verifyDir)
+	exportDir := decryptImport(absVerifyDir, decryptKey, decryptKeyPassfile, "")
+	if verifyCertFile == "hubserver.pem" {
+		verifyCertFile = path.Join(exportDir, verifyCertFile)
+	}
+	failures := verifyExport(exportDir, verifyCertFile, verifyCaFile, payloadDir)
+	// the decrypted files are not needed anymore, the encrypted files were authenticated while decrypting them
+	runCleanups()
+	if len(failures) > 0 {
+		for _, failure := range failures {
+			fmt.Println(failure)
+		}
+		log.Fatal().Msgf("Verification of %s failed with %d errors", absVerifyDir, len(failures))
+	}
+	log.Info().Msgf("Export %s verified", absVerifyDir)
+}
+
+// verifyExport checks the certificate chain, the signatures of the SQL file and the manifest
+// and every exported file against the manifest. Separately delivered package and image files are checked
+// in payloadDir. It returns the failures found.
+func verifyExport(absVerifyDir string, certFile string, caFile string, payloadDir string) []string {
+	failures := make([]string, 0)
+	signedFiles := []string{validateFolder(absVerifyDir)}
+	manifestFile := path.Join(absVerifyDir, manifest.FileName)
+	if _, err := os.Stat(manifestFile); err == nil {
+		signedFiles = append(signedFiles, manifestFile)
+	} else {
+		failures = append(failures, fmt.Sprintf("%s: missing, package and image files cannot be verified", manifest.FileName))
+	}
+
+	if _, err := os.Stat(certFile); err != nil {
+		failures = append(failures, fmt.Sprintf("certificate %s: not found, signatures cannot be verified", certFile))
+	} else if err := utils.ValidateCertificate(certFile, caFile); err != nil {
+		failures = append(failures, fmt.Sprintf("certificate %s: %s", certFile, err))
+	} else {
+		for _, signedFile := range signedFiles {
+			if err := utils.VerifySignature(signedFile, certFile); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: invalid signature: %s", path.Base(signedFile), err))
+			}
+		}
+	}
+
+	failures = append(failures, verifyPayloadFiles(absVerifyDir, payloadDir)...)
+
+	if len(signedFiles) == 1 {
+		return failures
+	}
+	exportManifest, err := manifest.Read(absVerifyDir)
+	if err != nil {
+		return append(failures, fmt.Sprintf("%s: %s", manifest.FileName, err))
+	}
+	problems, err := exportManifest.Verify(absVerifyDir)
+	if err != nil {
+		return append(failures, fmt.Sprintf("%s: unable to verify files: %s", manifest.FileName, err))
+	}
+	return append(failures, problems...)
+}
+
+// verifyPayloadFiles checks the package and image files referenced by the export were delivered to payloadDir
+func verifyPayloadFiles(absVerifyDir string, payloadDir string) []string {
+	entries, err := payload.Read(absVerifyDir)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %s", payload.FileListName, err)}
+	}
+	if entries == nil {
+		if len(payloadDir) > 0 {
+			log.Warn().Msg("Export contains the package and image files, `--payloadDir` is ignored")
+		}
+		return nil
+	}
+	if len(payloadDir) == 0 {
+		return []string{fmt.Sprintf("%s: %d package and image files referenced, use `--payloadDir` to verify them",
+			payload.FileListName, len(entries))}
+	}
+	return payload.Verify(utils.GetAbsPath(payloadDir), entries)
+}
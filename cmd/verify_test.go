@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/uyuni-project/inter-server-sync/manifest"
+	"github.com/uyuni-project/inter-server-sync/payload"
+)
+
+func TestVerifyExport(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(path.Join(dir, "packages"), 0755)
+	for name, content := range map[string]string{
+		"sql_statements.sql":    "BEGIN;\nCOMMIT;\n",
+		"packages/test-1.0.rpm": "package",
+		"packages/test-2.0.rpm": "package",
+		"packages/test-3.0.rpm": "package",
+		"exportedChannels.txt":  "",
+		"version.txt":           "version = 1\n",
+	} {
+		if err := os.WriteFile(path.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exportManifest, err := manifest.Create(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exportManifest.Write(dir); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(path.Join(dir, "packages/test-1.0.rpm"), []byte("tampered"), 0644)
+	os.Remove(path.Join(dir, "packages/test-2.0.rpm"))
+
+	certFile := path.Join(dir, "hubserver.pem")
+	failures := verifyExport(dir, certFile, "", "")
+	expected := []string{
+		"certificate " + certFile + ": not found, signatures cannot be verified",
+		"packages/test-1.0.rpm: size 8, expected 7",
+		"packages/test-2.0.rpm: missing",
+	}
+	if !reflect.DeepEqual(failures, expected) {
+		t.Errorf("unexpected failures: %v", failures)
+	}
+}
+
+// createReferencingExport creates an export referencing a package and an image file and delivers them to a payload directory
+func createReferencingExport(t *testing.T) (string, string) {
+	sourceDir := t.TempDir()
+	exportDir := t.TempDir()
+	payloadDir := t.TempDir()
+	for name, content := range map[string]string{
+		path.Join(sourceDir, "packages/1/a.rpm"):   "package a",
+		path.Join(sourceDir, "os-images/1/image"):  "image",
+		path.Join(exportDir, "sql_statements.sql"): "BEGIN;\nCOMMIT;\n",
+		path.Join(exportDir, "version.txt"):        "version = 1\n",
+		path.Join(payloadDir, "packages/1/a.rpm"):  "package a",
+		path.Join(payloadDir, "images/1/image"):    "image",
+	} {
+		os.MkdirAll(path.Dir(name), 0755)
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := payload.Create(exportDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sha256 of "package a"
+	if err := list.AddPackage(path.Join(sourceDir, "packages/1/a.rpm"), "packages/1/a.rpm", "sha256",
+		"7663fa2eaf2e6846391a250cc37947941ffda1650e53cdee850c32f56e277971"); err != nil {
+		t.Fatal(err)
+	}
+	if err := list.AddImage(path.Join(sourceDir, "os-images/1/image"), "1/image"); err != nil {
+		t.Fatal(err)
+	}
+	if err := list.Close(); err != nil {
+		t.Fatal(err)
+	}
+	exportManifest, err := manifest.Create(exportDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exportManifest.Write(exportDir); err != nil {
+		t.Fatal(err)
+	}
+	return exportDir, payloadDir
+}
+
+func TestVerifyExportPayload(t *testing.T) {
+	exportDir, payloadDir := createReferencingExport(t)
+	certFile := path.Join(exportDir, "hubserver.pem")
+	certFailure := "certificate " + certFile + ": not found, signatures cannot be verified"
+
+	failures := verifyExport(exportDir, certFile, "", "")
+	expected := []string{certFailure, payload.FileListName + ": 2 package and image files referenced, use `--payloadDir` to verify them"}
+	if !reflect.DeepEqual(failures, expected) {
+		t.Errorf("unexpected failures without payload directory: %v", failures)
+	}
+
+	if failures := verifyExport(exportDir, certFile, "", payloadDir); !reflect.DeepEqual(failures, []string{certFailure}) {
+		t.Errorf("unexpected failures: %v", failures)
+	}
+
+	os.WriteFile(path.Join(payloadDir, "packages/1/a.rpm"), []byte("package b"), 0644)
+	os.Remove(path.Join(payloadDir, "images/1/image"))
+	failures = verifyExport(exportDir, certFile, "", payloadDir)
+	expected = []string{certFailure, "images/1/image: missing", "packages/1/a.rpm: checksum mismatch"}
+	if !reflect.DeepEqual(failures, expected) {
+		t.Errorf("unexpected failures of changed files: %v", failures)
+	}
+}
@@ -0,0 +1,390 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cobbler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+const (
+	// AutoinstallDir is where the server keeps the autoinstallation files of the profiles
+	AutoinstallDir = "/var/lib/rhn/kickstarts"
+	// AutoinstallExportDir is the directory of the export the autoinstallation files are copied into
+	AutoinstallExportDir = "autoinstall"
+	// AutoinstallFileListName lists the exported profiles with their organization and autoinstallation file,
+	// see AutoinstallFile
+	AutoinstallFileListName = "autoinstallFiles.json"
+)
+
+// AutoinstallFile is an exported profile and its autoinstallation file
+type AutoinstallFile struct {
+	Label string `json:"label"`
+	// Org is the name of the organization of the profile on the source server
+	Org string `json:"org"`
+	// Path is relative to the export directory, empty when the profile has no exported autoinstallation file
+	Path string `json:"path,omitempty"`
+}
+
+// ImportedProfile is an imported autoinstallation profile, Org is the name of its organization on the target server
+type ImportedProfile struct {
+	Label string
+	Org   string
+}
+
+// ImportedAutoinstall are the distributions and profiles of the import cobbler entries are created for
+type ImportedAutoinstall struct {
+	Distributions []string
+	Profiles      []ImportedProfile
+	// ProfileFile returns the exported autoinstallation file of the profile of the organization, empty if none
+	ProfileFile func(label string, org string) string
+}
+
+type Distribution struct {
+	Id                string
+	Label             string
+	BasePath          string
+	Org               string
+	OrgId             string
+	Arch              string
+	InstallType       string
+	KernelOptions     string
+	KernelOptionsPost string
+}
+
+func (d Distribution) String() string {
+	return fmt.Sprintf("Distribution id %s, label %s, path %s, arch %s, install type %s under orgid %s, org %s",
+		d.Id, d.Label, d.BasePath, d.Arch, d.InstallType, d.OrgId, d.Org)
+}
+
+type AutoinstallProfile struct {
+	Id            string
+	Label         string
+	Org           string
+	OrgId         string
+	Tree          string
+	TreeOrg       string
+	TreeOrgId     string
+	KernelOptions string
+	Type          string
+}
+
+func (p AutoinstallProfile) String() string {
+	return fmt.Sprintf("Autoinstallation profile id %s, label %s, distribution %s under orgid %s, org %s",
+		p.Id, p.Label, p.Tree, p.OrgId, p.Org)
+}
+
+// For unit testing
+var fileExists = func(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+//// Distributions
+
+// processDistributions creates cobbler distros for the imported distributions.
+// Imported distributions have no cobbler id, it is set once the distro is created.
+func processDistributions(db *sql.DB, labels []string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	errs := make([]error, 0)
+	dbdistributions := sqlUtil.ExecuteQueryWithResults(db,
+		`SELECT t.id::text, t.label, t.base_path, COALESCE(t.org_id::text, '') AS orgid, COALESCE(wc.name, '') AS orgname,
+		ca.label AS arch, it.label AS installtype,
+		COALESCE(t.kernel_options, '') AS kerneloptions, COALESCE(t.kernel_options_post, '') AS kerneloptionspost FROM
+		rhnkickstartabletree t INNER JOIN rhnchannel c ON t.channel_id = c.id
+		INNER JOIN rhnchannelarch ca ON c.channel_arch_id = ca.id
+		INNER JOIN rhnksinstalltype it ON t.install_type = it.id
+		LEFT JOIN web_customer wc ON t.org_id = wc.id WHERE t.cobbler_id IS NULL AND t.label = ANY($1)`, pq.Array(labels))
+	for _, dbdistribution := range dbdistributions {
+		distribution := Distribution{}
+		for _, column := range dbdistribution {
+			switch column.ColumnName {
+			case "id":
+				distribution.Id = column.Value.(string)
+			case "label":
+				distribution.Label = column.Value.(string)
+			case "base_path":
+				distribution.BasePath = column.Value.(string)
+			case "orgid":
+				distribution.OrgId = column.Value.(string)
+			case "orgname":
+				distribution.Org = column.Value.(string)
+			case "arch":
+				distribution.Arch = column.Value.(string)
+			case "installtype":
+				distribution.InstallType = column.Value.(string)
+			case "kerneloptions":
+				distribution.KernelOptions = column.Value.(string)
+			case "kerneloptionspost":
+				distribution.KernelOptionsPost = column.Value.(string)
+			default:
+				log.Debug().Msgf("Unexpected column %s", column.ColumnName)
+			}
+		}
+		log.Debug().Msg(distribution.String())
+		if err := createAutoinstallDistroEntry(db, distribution); err != nil {
+			log.Error().Err(err).Msgf("Error when creating cobbler entry for distribution %s", distribution.Label)
+			errs = append(errs, fmt.Errorf("distribution %s: %w", distribution.Label, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func createAutoinstallDistroEntry(db *sql.DB, distribution Distribution) error {
+	name := makeAutoinstallCobblerName(distribution.Label, distribution.Org, distribution.OrgId)
+	arch := cobblerArch(distribution.Arch)
+	kernel, initrd := findKernelAndInitrd(distribution.BasePath, arch)
+	if len(kernel) == 0 || len(initrd) == 0 {
+		return fmt.Errorf("unable to find kernel and initrd of distribution %s in %s", distribution.Label, distribution.BasePath)
+	}
+
+	exists, err := cobblerItemExists(name, "distro")
+	if err != nil {
+		return err
+	}
+	if !exists {
+		log.Debug().Msgf("Creating cobbler distro %s", name)
+		if err := cmd(COBBLER, "distro", "add", "--name", name, "--kernel", kernel, "--initrd", initrd,
+			"--arch", arch, "--breed", cobblerBreed(distribution.InstallType),
+			"--kernel-options", distribution.KernelOptions,
+			"--kernel-options-post", distribution.KernelOptionsPost).Run(); err != nil {
+			return err
+		}
+	}
+	return updateCobblerId(db, "rhnkickstartabletree", distribution.Id, name, "distro")
+}
+
+//// Profiles
+
+// processAutoinstallProfiles creates or updates the cobbler profiles of the imported autoinstallation profiles.
+// Profiles with the same label in other organizations are not changed.
+func processAutoinstallProfiles(db *sql.DB, autoinstall ImportedAutoinstall) error {
+	if len(autoinstall.Profiles) == 0 {
+		return nil
+	}
+	labels := make([]string, 0, len(autoinstall.Profiles))
+	orgs := make([]string, 0, len(autoinstall.Profiles))
+	for _, profile := range autoinstall.Profiles {
+		labels = append(labels, profile.Label)
+		orgs = append(orgs, profile.Org)
+	}
+	errs := make([]error, 0)
+	dbprofiles := sqlUtil.ExecuteQueryWithResults(db,
+		`SELECT ks.id::text, ks.label, ks.org_id::text AS orgid, wc.name AS orgname, ks.ks_type AS type,
+		t.label AS tree, COALESCE(t.org_id::text, '') AS treeorgid, COALESCE(twc.name, '') AS treeorgname,
+		COALESCE(ks.kernel_params, '') AS kerneloptions FROM
+		rhnksdata ks INNER JOIN web_customer wc ON ks.org_id = wc.id
+		INNER JOIN rhnkickstartdefaults kd ON kd.kickstart_id = ks.id
+		INNER JOIN rhnkickstartabletree t ON kd.kstree_id = t.id
+		LEFT JOIN web_customer twc ON t.org_id = twc.id
+		WHERE (ks.label, wc.name) IN (SELECT * FROM unnest($1::text[], $2::text[]))`, pq.Array(labels), pq.Array(orgs))
+	for _, dbprofile := range dbprofiles {
+		profile := AutoinstallProfile{}
+		for _, column := range dbprofile {
+			switch column.ColumnName {
+			case "id":
+				profile.Id = column.Value.(string)
+			case "label":
+				profile.Label = column.Value.(string)
+			case "orgid":
+				profile.OrgId = column.Value.(string)
+			case "orgname":
+				profile.Org = column.Value.(string)
+			case "tree":
+				profile.Tree = column.Value.(string)
+			case "treeorgid":
+				profile.TreeOrgId = column.Value.(string)
+			case "treeorgname":
+				profile.TreeOrg = column.Value.(string)
+			case "kerneloptions":
+				profile.KernelOptions = column.Value.(string)
+			case "type":
+				profile.Type = column.Value.(string)
+			default:
+				log.Debug().Msgf("Unexpected column %s", column.ColumnName)
+			}
+		}
+		log.Debug().Msg(profile.String())
+		var autoinstallFile string
+		if autoinstall.ProfileFile != nil {
+			autoinstallFile = autoinstall.ProfileFile(profile.Label, profile.Org)
+		}
+		if err := createAutoinstallProfileEntry(db, profile, autoinstallFile); err != nil {
+			log.Error().Err(err).Msgf("Error when creating cobbler entry for autoinstallation profile %s", profile.Label)
+			errs = append(errs, fmt.Errorf("autoinstallation profile %s: %w", profile.Label, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// createAutoinstallProfileEntry installs the exported autoinstallation file, if any, and creates the cobbler profile
+func createAutoinstallProfileEntry(db *sql.DB, profile AutoinstallProfile, exportedFile string) error {
+	name := makeAutoinstallCobblerName(profile.Label, profile.Org, profile.OrgId)
+	distro := makeAutoinstallCobblerName(profile.Tree, profile.TreeOrg, profile.TreeOrgId)
+	log.Debug().Msgf("Creating cobbler profile %s", name)
+
+	args := []string{"--name", name, "--distro", distro, "--kernel-options", profile.KernelOptions}
+	if len(exportedFile) > 0 {
+		autoinstallFile := AutoinstallFileName(profile.Label, profile.Type, profile.OrgId)
+		if err := installAutoinstallFile(exportedFile, path.Join(AutoinstallDir, autoinstallFile)); err != nil {
+			return err
+		}
+		args = append(args, "--autoinstall", autoinstallFile)
+	} else {
+		log.Warn().Msgf("Autoinstallation profile %s has no exported autoinstallation file", profile.Label)
+	}
+
+	exists, err := cobblerItemExists(name, "profile")
+	if err != nil {
+		return err
+	}
+	action := "add"
+	if exists {
+		action = "edit"
+	}
+	if err := cmd(COBBLER, append([]string{"profile", action}, args...)...).Run(); err != nil {
+		log.Error().Msgf("Error %sing profile %s with distro %s", action, name, distro)
+		return err
+	}
+	return updateCobblerId(db, "rhnksdata", profile.Id, name, "profile")
+}
+
+//// Utils
+
+/*
+AutoinstallFileName returns the file of the profile relative to AutoinstallDir, as the server names it:
+
+	upload/label--orgid.cfg for raw profiles, wizard/label--orgid.cfg otherwise
+*/
+func AutoinstallFileName(label string, ksType string, orgid string) string {
+	dir := "wizard"
+	if ksType == "raw" {
+		dir = "upload"
+	}
+	return path.Join(dir, fmt.Sprintf("%s--%s.cfg", strings.ReplaceAll(label, " ", "_"), orgid))
+}
+
+// installAutoinstallFile copies the exported autoinstallation file to the place the server reads it from,
+// owned by the owner of the directory
+func installAutoinstallFile(exportedFile string, target string) error {
+	if path.Dir(path.Dir(target)) != AutoinstallDir {
+		return fmt.Errorf("invalid autoinstallation file %s", target)
+	}
+	content, err := os.ReadFile(exportedFile)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(target, content, 0644); err != nil {
+		return err
+	}
+	info, err := os.Stat(path.Dir(target))
+	if err != nil {
+		return err
+	}
+	if owner, ok := info.Sys().(*syscall.Stat_t); ok {
+		return os.Chown(target, int(owner.Uid), int(owner.Gid))
+	}
+	return nil
+}
+
+// updateCobblerId links the database entry to the cobbler item
+func updateCobblerId(db *sql.DB, table string, id string, name string, item string) error {
+	out, err := cmd(COBBLER, item, "report", "--name", name).Output()
+	if err != nil {
+		log.Error().Msgf("Failed to query for %s %s", item, name)
+		return err
+	}
+	uid := parseCobblerUid(string(out))
+	if len(uid) == 0 {
+		return fmt.Errorf("unable to find cobbler id of %s %s", item, name)
+	}
+	_, err = db.Exec(fmt.Sprintf("UPDATE %s SET cobbler_id = $1 WHERE id = $2", table), uid, id)
+	return err
+}
+
+// parseCobblerUid returns the internal UID from the cobbler report output
+func parseCobblerUid(report string) string {
+	for _, line := range strings.Split(report, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "Internal UID") {
+			if parts := strings.SplitN(line, ":", 2); len(parts) == 2 {
+				return strings.TrimSpace(parts[1])
+			}
+		}
+	}
+	return ""
+}
+
+/*
+makeAutoinstallCobblerName returns the name the server uses for autoinstallation entries:
+
+	label:orgid:org or label for vendor entries
+*/
+func makeAutoinstallCobblerName(label string, org string, orgid string) string {
+	if len(orgid) == 0 {
+		return label
+	}
+	return strings.Join([]string{label, orgid, sanitizeOrg(org)}, ":")
+}
+
+// cobblerArch converts the channel architecture label to the cobbler architecture
+func cobblerArch(channelArch string) string {
+	arch := strings.TrimPrefix(channelArch, "channel-")
+	switch arch {
+	case "ia32":
+		return "i386"
+	case "amd64-deb":
+		return "x86_64"
+	default:
+		return arch
+	}
+}
+
+// cobblerBreed converts the install type label to the cobbler breed
+func cobblerBreed(installType string) string {
+	switch {
+	case strings.HasPrefix(installType, "sles"), strings.HasPrefix(installType, "suse"):
+		return "suse"
+	case strings.HasPrefix(installType, "rhel"), strings.HasPrefix(installType, "fedora"),
+		strings.HasPrefix(installType, "generic_rpm"):
+		return "redhat"
+	case strings.HasPrefix(installType, "ubuntu"):
+		return "ubuntu"
+	case strings.HasPrefix(installType, "debian"):
+		return "debian"
+	default:
+		return "generic"
+	}
+}
+
+// findKernelAndInitrd looks for the installer kernel and initrd in the known locations of the installation tree
+func findKernelAndInitrd(basePath string, arch string) (string, string) {
+	candidates := [][]string{
+		{"images/pxeboot/vmlinuz", "images/pxeboot/initrd.img"},
+		{path.Join("boot", arch, "loader/linux"), path.Join("boot", arch, "loader/initrd")},
+		{path.Join("boot", arch, "linux"), path.Join("boot", arch, "initrd")},
+	}
+	for _, candidate := range candidates {
+		kernel := path.Join(basePath, candidate[0])
+		initrd := path.Join(basePath, candidate[1])
+		if fileExists(kernel) && fileExists(initrd) {
+			return kernel, initrd
+		}
+	}
+	return "", ""
+}
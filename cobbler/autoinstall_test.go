@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cobbler
+
+import (
+	"path"
+	"testing"
+)
+
+func TestMakeAutoinstallCobblerName(t *testing.T) {
+	tests := []struct {
+		label    string
+		org      string
+		orgid    string
+		expected string
+	}{
+		{"sles15sp6", "", "", "sles15sp6"},
+		{"sles15sp6", "My Org", "2", "sles15sp6:2:MyOrg"},
+	}
+
+	for _, tt := range tests {
+		result := makeAutoinstallCobblerName(tt.label, tt.org, tt.orgid)
+		if result != tt.expected {
+			t.Errorf("makeAutoinstallCobblerName(%q, %q, %q) = %q, want %q", tt.label, tt.org, tt.orgid, result, tt.expected)
+		}
+	}
+}
+
+func TestParseCobblerUid(t *testing.T) {
+	report := "Name                           : sles15sp6\nInternal UID                   : 6ad1f7f1c8e54d2b\nKernel                         : /srv/linux\n"
+	if uid := parseCobblerUid(report); uid != "6ad1f7f1c8e54d2b" {
+		t.Errorf("parseCobblerUid() = %q, want %q", uid, "6ad1f7f1c8e54d2b")
+	}
+	if uid := parseCobblerUid("No such item"); uid != "" {
+		t.Errorf("parseCobblerUid() = %q, want empty", uid)
+	}
+}
+
+func TestFindKernelAndInitrd(t *testing.T) {
+	existing := map[string]bool{
+		"/srv/sles/boot/x86_64/loader/linux":  true,
+		"/srv/sles/boot/x86_64/loader/initrd": true,
+		"/srv/rhel/images/pxeboot/vmlinuz":    true,
+	}
+	fileExists = func(name string) bool { return existing[name] }
+
+	kernel, initrd := findKernelAndInitrd("/srv/sles", cobblerArch("channel-x86_64"))
+	if kernel != "/srv/sles/boot/x86_64/loader/linux" || initrd != "/srv/sles/boot/x86_64/loader/initrd" {
+		t.Errorf("unexpected kernel %q and initrd %q", kernel, initrd)
+	}
+	if kernel, initrd = findKernelAndInitrd("/srv/rhel", "x86_64"); kernel != "" || initrd != "" {
+		t.Errorf("incomplete tree should not be used, got kernel %q and initrd %q", kernel, initrd)
+	}
+}
+
+func TestAutoinstallFileName(t *testing.T) {
+	if name := AutoinstallFileName("my profile", "raw", "2"); name != "upload/my_profile--2.cfg" {
+		t.Errorf("unexpected raw profile file %q", name)
+	}
+	if name := AutoinstallFileName("sles", "wizard", "1"); name != "wizard/sles--1.cfg" {
+		t.Errorf("unexpected wizard profile file %q", name)
+	}
+	if err := installAutoinstallFile("/dev/null", path.Join(AutoinstallDir, AutoinstallFileName("../../etc/x", "raw", "1"))); err == nil {
+		t.Error("autoinstallation file outside of the autoinstallation directory accepted")
+	}
+}
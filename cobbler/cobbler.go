@@ -6,6 +6,7 @@ package cobbler
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os/exec"
 	"path"
@@ -66,13 +67,20 @@ func (g Group) String() string {
 		g.BranchId, g.Server, g.Image, g.ImageVersion, g.OrgId, g.Org, g.KernelLine)
 }
 
-func RecreateCobblerEntities(serverconfig string) error {
+// RecreateCobblerEntities creates the cobbler entries of the images and of the imported autoinstallation
+// distributions and profiles. Errors of single distributions and profiles are returned together.
+func RecreateCobblerEntities(serverconfig string, autoinstall ImportedAutoinstall) error {
 	db := schemareader.GetDBconnection(serverconfig)
 	if err := processImages(db); err != nil {
 		return err
 	}
 	log.Info().Msg("Recomputing saltboot groups")
-	return processGroups(db)
+	if err := processGroups(db); err != nil {
+		return err
+	}
+	log.Info().Msg("Creating autoinstallation distributions and profiles")
+	// profiles of failed distributions fail as well, their errors are reported too
+	return errors.Join(processDistributions(db, autoinstall.Distributions), processAutoinstallProfiles(db, autoinstall))
 }
 
 //// Groups
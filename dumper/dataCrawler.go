@@ -185,7 +185,8 @@ func followReferencesFrom(db *sql.DB, schemaMetadata map[string]schemareader.Tab
 
 func shouldFollowToLinkPreOrder(path []string, currentTable schemareader.Table, referencedTable schemareader.Table) bool {
 	forbiddenNavigations := map[string][]string{
-		"rhnconfigfile": {"rhnconfigrevision"},
+		"rhnconfigfile":      {"rhnconfigrevision"},
+		"susecontentproject": {"susecontentenvironment"},
 	}
 
 	if tableNavigation, ok := forbiddenNavigations[currentTable.Name]; ok {
@@ -208,13 +209,17 @@ func shouldFollowReferenceToLink(path []string, currentTable schemareader.Table,
 	}
 
 	forcedNavigations := map[string][]string{
-		"rhnchannelfamily": {"rhnpublicchannelfamily"},
-		"rhnchannel":       {"susemddata", "suseproductchannel", "rhnreleasechannelmap", "rhndistchannelmap", "rhnerratafilechannel"},
-		"suseproducts":     {"suseproductextension", "susechanneltemplate"},
-		"rhnpackageevr":    {"rhnpackagenevra"},
-		"rhnerrata":        {"rhnerratafile"},
-		"rhnconfigchannel": {"rhnconfigfile"},
-		"rhnconfigfile":    {"rhnconfigrevision"},
+		"rhnchannelfamily":       {"rhnpublicchannelfamily"},
+		"rhnchannel":             {"susemddata", "suseproductchannel", "rhnreleasechannelmap", "rhndistchannelmap", "rhnerratafilechannel"},
+		"suseproducts":           {"suseproductextension", "susechanneltemplate"},
+		"rhnpackageevr":          {"rhnpackagenevra"},
+		"rhnerrata":              {"rhnerratafile"},
+		"rhnconfigchannel":       {"rhnconfigfile"},
+		"rhnconfigfile":          {"rhnconfigrevision"},
+		"rhncontentsource":       {"rhncontentssl"},
+		"rhnksdata":              {"rhnkickstartdefaults", "rhnkickstartcommand", "rhnksscript", "rhnkickstartpackage", "rhnkickstartchildchannel", "rhnkickstartdefaultregtoken", "rhncryptokeykickstart", "rhnkickstartipranges"},
+		"susecontentproject":     {"susecontentenvironment", "susecontentprojectsource", "susecontentprojectfilter"},
+		"susecontentenvironment": {"susecontentenvironmenttarget"},
 	}
 
 	if tableNavigation, ok := forcedNavigations[currentTable.Name]; ok {
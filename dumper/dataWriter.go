@@ -275,6 +275,9 @@ func SubstituteForeignKey(db *sql.DB, table schemareader.Table, tables map[strin
 func substituteForeignKeyReference(db *sql.DB, table schemareader.Table,
 	tables map[string]schemareader.Table, reference schemareader.Reference, row []sqlUtil.RowDataStructure) []sqlUtil.RowDataStructure {
 	foreignTable := tables[reference.TableName]
+	if foreignTable.NaturalKeyFrom != nil {
+		return substituteNaturalKeyReference(db, table, foreignTable, reference, row)
+	}
 
 	foreignMainUniqueColumns := foreignTable.UniqueIndexes[foreignTable.MainUniqueIndexName].Columns
 	localColumns := make([]string, 0)
@@ -361,6 +364,112 @@ func substituteForeignKeyReference(db *sql.DB, table schemareader.Table,
 	return row
 }
 
+// substituteNaturalKeyReference replaces the reference to a table without natural key by a lookup through the key
+// of the table referencing it. Rows of the referencing table itself fall back to the row inserted right before them.
+func substituteNaturalKeyReference(db *sql.DB, table schemareader.Table, foreignTable schemareader.Table,
+	reference schemareader.Reference, row []sqlUtil.RowDataStructure) []sqlUtil.RowDataStructure {
+	naturalKey := *foreignTable.NaturalKeyFrom
+	for localColumn := range reference.ColumnMapping {
+		column := &row[table.ColumnIndexes[localColumn]]
+		if column.Value == nil {
+			continue
+		}
+		key, ok := lookupNaturalKey(db, naturalKey, column.Value)
+		if !ok {
+			continue
+		}
+		column.Value = naturalKeySelect(naturalKey, key)
+		if table.Name == naturalKey.Table {
+			column.Value = fmt.Sprintf("SELECT COALESCE((%s), currval('%s'))", column.Value, foreignTable.PKSequence)
+		}
+		column.ColumnType = "SQL"
+	}
+	return row
+}
+
+// lookupNaturalKey returns the key identifying the row with the given id on the source server
+func lookupNaturalKey(db *sql.DB, naturalKey schemareader.NaturalKeyReference, id interface{}) (string, bool) {
+	cacheKey := fmt.Sprintf("%s.%s,%v", naturalKey.Table, naturalKey.Column, id)
+	if key, found := cache[cacheKey]; found {
+		return key, true
+	}
+	rows := sqlUtil.ExecuteQueryWithResults(db,
+		fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1;", naturalKey.Key, naturalKey.Table, naturalKey.Column), id)
+	if len(rows) == 0 || rows[0][0].Value == nil {
+		return "", false
+	}
+	key := fmt.Sprintf("%s", rows[0][0].Value)
+	cache[cacheKey] = key
+	return key, true
+}
+
+// naturalKeySelect selects the id of the row identified by the key on the target server
+func naturalKeySelect(naturalKey schemareader.NaturalKeyReference, key string) string {
+	return fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", naturalKey.Column, naturalKey.Table, naturalKey.Key, pq.QuoteLiteral(key))
+}
+
+// generateNaturalKeyRowStatements updates the row identified through the key of the table referencing it,
+// or inserts it when the key does not exist on the target server yet
+func generateNaturalKeyRowStatements(db *sql.DB, originalValues []sqlUtil.RowDataStructure, values []sqlUtil.RowDataStructure,
+	table schemareader.Table, columnNames string) string {
+	naturalKey := *table.NaturalKeyFrom
+	var pkColumn string
+	var id interface{}
+	for _, value := range originalValues {
+		if table.PKColumns[value.ColumnName] {
+			pkColumn = value.ColumnName
+			id = value.Value
+		}
+	}
+	key, ok := lookupNaturalKey(db, naturalKey, id)
+	if !ok {
+		log.Warn().Msgf("%s row %v is not referenced by %s, it is not exported", table.Name, id, naturalKey.Table)
+		return fmt.Sprintf("-- %s row %v skipped, not referenced by %s", table.Name, id, naturalKey.Table)
+	}
+	keySelect := naturalKeySelect(naturalKey, key)
+	assignments := make([]string, 0)
+	for _, value := range values {
+		if !table.PKColumns[value.ColumnName] {
+			assignments = append(assignments, fmt.Sprintf("%s = %s", value.ColumnName, formatField(value)))
+		}
+	}
+	update := fmt.Sprintf("UPDATE %s SET %s WHERE %s = (%s);", table.Name, strings.Join(assignments, ", "), pkColumn, keySelect)
+	insert := fmt.Sprintf(`INSERT INTO %s (%s)	SELECT %s WHERE NOT EXISTS (%s);`,
+		table.Name, columnNames, formatRowValue(values), keySelect)
+	return update + "\n" + insert
+}
+
+// GenerateRowUpdateStatement generates an update of the given columns for the row identified by the table main unique index.
+// It is used for columns which cannot be exported with the row itself, like circular references.
+func GenerateRowUpdateStatement(db *sql.DB, values []sqlUtil.RowDataStructure, table schemareader.Table,
+	schemaMetadata map[string]schemareader.Table, columns []string) string {
+
+	rowKeysProcessed := SubstituteForeignKey(db, table, schemaMetadata, values)
+
+	assignments := make([]string, 0)
+	for _, column := range columns {
+		for _, value := range rowKeysProcessed {
+			if strings.Compare(column, value.ColumnName) == 0 {
+				assignments = append(assignments, fmt.Sprintf("%s = %s", value.ColumnName, formatField(value)))
+			}
+		}
+	}
+
+	whereClauseList := make([]string, 0)
+	for _, indexColumn := range table.UniqueIndexes[table.MainUniqueIndexName].Columns {
+		for _, value := range rowKeysProcessed {
+			if strings.Compare(indexColumn, value.ColumnName) == 0 {
+				if value.Value == nil {
+					whereClauseList = append(whereClauseList, fmt.Sprintf("%s IS NULL", value.ColumnName))
+				} else {
+					whereClauseList = append(whereClauseList, fmt.Sprintf("%s = %s", value.ColumnName, formatField(value)))
+				}
+			}
+		}
+	}
+	return fmt.Sprintf("UPDATE %s SET %s WHERE %s;", table.Name, strings.Join(assignments, ", "), strings.Join(whereClauseList, " AND "))
+}
+
 func formatRowValue(value []sqlUtil.RowDataStructure) string {
 	result := make([]string, 0)
 	for _, col := range value {
@@ -584,6 +693,10 @@ func generateRowInsertStatement(db *sql.DB, values []sqlUtil.RowDataStructure, t
 	rowKeysProcessed := substituteKeys(db, table, values, schemaMetadata)
 	valueFiltered := filterRowData(rowKeysProcessed, table)
 
+	if table.NaturalKeyFrom != nil {
+		return generateNaturalKeyRowStatements(db, values, valueFiltered, table, columnNames)
+	}
+
 	if strings.Compare(table.MainUniqueIndexName, schemareader.VirtualIndexName) == 0 || utils.Contains(onlyIfParentExistsTables, table.Name) {
 		whereClauseList := make([]string, 0)
 
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dumper
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Link modes of the exported package and image files
+const (
+	// LinkModeCopy writes a full copy of the file
+	LinkModeCopy = "copy"
+	// LinkModeHardlink adds a hard link to the file, the exported file shares the data and the metadata with the source
+	LinkModeHardlink = "hardlink"
+	// LinkModeReflink clones the file, the data blocks are shared until one of the files is modified
+	LinkModeReflink = "reflink"
+)
+
+// LinkModes lists all link modes
+var LinkModes = []string{LinkModeCopy, LinkModeHardlink, LinkModeReflink}
+
+// errReflinkNotSupported is returned by reflink on platforms without file cloning
+var errReflinkNotSupported = errors.New("reflinks are not supported on this platform")
+
+// ExportFile writes the file src to dst using the link mode and returns the number of bytes copied.
+// Links fall back to a copy when src and dst are on different filesystems or the filesystem cannot link the files.
+func ExportFile(src string, dst string, linkMode string) (int64, error) {
+	sourceFileStat, err := os.Stat(src)
+	if err != nil {
+		return 0, err
+	}
+	if !sourceFileStat.Mode().IsRegular() {
+		return 0, fmt.Errorf("%s is not a regular file", src)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0770); err != nil {
+		return 0, err
+	}
+	// an existing file can be a link to src, writing into it would modify src
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	switch linkMode {
+	case LinkModeCopy, "":
+		return Copy(src, dst)
+	case LinkModeHardlink:
+		err = os.Link(src, dst)
+	case LinkModeReflink:
+		err = reflink(src, dst)
+	default:
+		return 0, fmt.Errorf("unknown link mode %s", linkMode)
+	}
+	if err == nil {
+		return 0, nil
+	}
+	if !isLinkNotPossible(err) {
+		return 0, err
+	}
+	log.Trace().Err(err).Msgf("unable to %s %s, copying it", linkMode, src)
+	os.Remove(dst)
+	return Copy(src, dst)
+}
+
+// isLinkNotPossible checks if the link failed because of the filesystems, not because of the files
+func isLinkNotPossible(err error) bool {
+	return errors.Is(err, errReflinkNotSupported) ||
+		errors.Is(err, syscall.EXDEV) ||
+		errors.Is(err, syscall.EPERM) ||
+		errors.Is(err, syscall.EOPNOTSUPP) ||
+		errors.Is(err, syscall.ENOTSUP) ||
+		errors.Is(err, syscall.ENOTTY) ||
+		errors.Is(err, syscall.EINVAL) ||
+		errors.Is(err, syscall.EMLINK)
+}
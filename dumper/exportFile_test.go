@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dumper
+
+import (
+	"os"
+	"path"
+	"testing"
+)
+
+func TestExportFile(t *testing.T) {
+	dir := t.TempDir()
+	source := path.Join(dir, "source.rpm")
+	if err := os.WriteFile(source, []byte("package"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sourceInfo, _ := os.Stat(source)
+
+	linked := path.Join(dir, "export", "hardlink", "source.rpm")
+	if _, err := ExportFile(source, linked, LinkModeHardlink); err != nil {
+		t.Fatal(err)
+	}
+	if linkedInfo, err := os.Stat(linked); err != nil || !os.SameFile(sourceInfo, linkedInfo) {
+		t.Errorf("expected a hard link to the source file: %v", err)
+	}
+
+	// copying over the hard link must not truncate the source file
+	if written, err := ExportFile(source, linked, LinkModeCopy); err != nil || written != 7 {
+		t.Fatalf("unexpected copy result %d %v", written, err)
+	}
+	if linkedInfo, _ := os.Stat(linked); os.SameFile(sourceInfo, linkedInfo) {
+		t.Error("expected a copy of the source file")
+	}
+	if content, _ := os.ReadFile(source); string(content) != "package" {
+		t.Errorf("source file modified: %q", content)
+	}
+
+	// filesystems without reflinks fall back to a copy
+	cloned := path.Join(dir, "export", "reflink", "source.rpm")
+	if _, err := ExportFile(source, cloned, LinkModeReflink); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(cloned); string(content) != "package" {
+		t.Errorf("unexpected content of the cloned file: %q", content)
+	}
+
+	if _, err := ExportFile(source, cloned, "symlink"); err == nil {
+		t.Error("expected error for unknown link mode")
+	}
+}
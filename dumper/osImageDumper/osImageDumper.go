@@ -8,16 +8,18 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 
 	"github.com/rs/zerolog/log"
 	"github.com/uyuni-project/inter-server-sync/dumper"
+	"github.com/uyuni-project/inter-server-sync/payload"
 )
 
 var serverDataFolder = "/srv/www/os-images/"
 
 //FIXME: we have no relation from db tables to actial data so for now copy content of serverDataFolder
 //func DumpOsImages(db *sql.DB, schemaMetadata map[string]schemareader.Table, data dumper.DataDumper, outputFolder string) {
-func DumpOsImages(outputFolder string, orgIds []uint) {
+func DumpOsImages(outputFolder string, orgIds []uint, linkMode string, payloadList *payload.List) {
 	log.Debug().Msg("Images data dump")
 
 	imagesDir, err := os.Open(serverDataFolder)
@@ -44,7 +46,7 @@ func DumpOsImages(outputFolder string, orgIds []uint) {
 
 				for _, image := range orgDirInfo {
 					if image.Type().IsRegular() {
-						DumpOsImage(path.Join(outputFolder, org.Name(), image.Name()), path.Join(orgDirPath, image.Name()))
+						DumpOsImage(path.Join(outputFolder, org.Name(), image.Name()), path.Join(orgDirPath, image.Name()), linkMode, payloadList)
 					}
 				}
 			}
@@ -52,9 +54,20 @@ func DumpOsImages(outputFolder string, orgIds []uint) {
 	}
 }
 
-func DumpOsImage(outputFolder string, source string) {
+// DumpOsImage copies the image file, with payloadList set the file is only recorded in the list
+func DumpOsImage(outputFolder string, source string, linkMode string, payloadList *payload.List) {
+	if payloadList != nil {
+		relativePath, err := filepath.Rel(serverDataFolder, source)
+		if err == nil {
+			err = payloadList.AddImage(source, relativePath)
+		}
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Unable to record image file %s", source)
+		}
+		return
+	}
 	log.Trace().Msgf("Copying image %s to %s", source, outputFolder)
-	_, err := dumper.Copy(source, outputFolder)
+	_, err := dumper.ExportFile(source, outputFolder, linkMode)
 	if err != nil {
 		log.Fatal().Err(err)
 	}
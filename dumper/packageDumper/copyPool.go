@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package packageDumper
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/utils"
+)
+
+// progressInterval is the minimal time between two progress reports of the package file copy
+const progressInterval = 30 * time.Second
+
+// packageFile is a package file to export
+type packageFile struct {
+	source       string
+	target       string
+	relativePath string
+	checksumId   interface{}
+}
+
+// copyFunc exports a single package file and returns the number of bytes written
+type copyFunc func(file packageFile) (int64, error)
+
+// copyPool exports package files by a fixed number of workers. Failures do not stop the workers,
+// they are collected and returned together once all files were processed.
+type copyPool struct {
+	total  int
+	files  chan packageFile
+	copy   copyFunc
+	wg     sync.WaitGroup
+	done   chan struct{}
+	copied atomic.Int64
+	bytes  atomic.Int64
+
+	errorsLock sync.Mutex
+	errors     []error
+}
+
+func newCopyPool(workers int, total int, copy copyFunc) *copyPool {
+	if workers < 1 {
+		workers = 1
+	}
+	pool := &copyPool{
+		total:  total,
+		files:  make(chan packageFile, workers*2),
+		copy:   copy,
+		done:   make(chan struct{}),
+		errors: make([]error, 0),
+	}
+	log.Info().Msgf("Exporting %d package files using %d workers", total, workers)
+	for i := 0; i < workers; i++ {
+		pool.wg.Add(1)
+		go pool.work()
+	}
+	go pool.reportProgress()
+	return pool
+}
+
+func (p *copyPool) work() {
+	defer p.wg.Done()
+	for file := range p.files {
+		written, err := p.copy(file)
+		if err != nil {
+			p.errorsLock.Lock()
+			p.errors = append(p.errors, fmt.Errorf("%s: %w", file.source, err))
+			p.errorsLock.Unlock()
+			continue
+		}
+		p.copied.Add(1)
+		p.bytes.Add(written)
+	}
+}
+
+func (p *copyPool) reportProgress() {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			log.Info().Msg(p.progress())
+		}
+	}
+}
+
+func (p *copyPool) progress() string {
+	p.errorsLock.Lock()
+	failed := len(p.errors)
+	p.errorsLock.Unlock()
+	return fmt.Sprintf("Exported %d of %d package files, %s written, %d failed",
+		p.copied.Load(), p.total, utils.FormatBytes(p.bytes.Load()), failed)
+}
+
+// add queues the file, it blocks while all workers are busy
+func (p *copyPool) add(file packageFile) {
+	p.files <- file
+}
+
+// wait waits for all queued files and returns the failures sorted by file
+func (p *copyPool) wait() []error {
+	close(p.files)
+	p.wg.Wait()
+	close(p.done)
+	log.Info().Msg(p.progress())
+	sort.Slice(p.errors, func(i, j int) bool { return p.errors[i].Error() < p.errors[j].Error() })
+	return p.errors
+}
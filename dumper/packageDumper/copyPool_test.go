@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package packageDumper
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/uyuni-project/inter-server-sync/dumper"
+)
+
+func TestCopyPool(t *testing.T) {
+	sourceDir := t.TempDir()
+	targetDir := t.TempDir()
+	pool := newCopyPool(3, 20, func(file packageFile) (int64, error) {
+		return dumper.Copy(file.source, file.target)
+	})
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("package-%02d.rpm", i)
+		// every fifth package file is missing
+		if i%5 != 0 {
+			if err := os.WriteFile(path.Join(sourceDir, name), []byte("package"), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		pool.add(packageFile{source: path.Join(sourceDir, name), target: path.Join(targetDir, "packages", name)})
+	}
+	failures := pool.wait()
+
+	if len(failures) != 4 {
+		t.Fatalf("expected 4 failures, got %v", failures)
+	}
+	if !strings.Contains(failures[0].Error(), "package-00.rpm") || !strings.Contains(failures[3].Error(), "package-15.rpm") {
+		t.Errorf("failures not sorted by file: %v", failures)
+	}
+	if progress := pool.progress(); progress != "Exported 16 of 20 package files, 112 B written, 4 failed" {
+		t.Errorf("unexpected progress %s", progress)
+	}
+	if _, err := os.Stat(path.Join(targetDir, "packages", "package-19.rpm")); err != nil {
+		t.Errorf("package file not copied: %s", err)
+	}
+}
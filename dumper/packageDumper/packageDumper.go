@@ -8,40 +8,67 @@ import (
 	"database/sql"
 	"fmt"
 	"github.com/rs/zerolog/log"
-	"time"
+	"os"
+	"sync/atomic"
 
 	"github.com/uyuni-project/inter-server-sync/dumper"
+	"github.com/uyuni-project/inter-server-sync/inventory"
+	"github.com/uyuni-project/inter-server-sync/payload"
 	"github.com/uyuni-project/inter-server-sync/schemareader"
+	"github.com/uyuni-project/inter-server-sync/utils"
 )
 
 var serverDataFolder = "/var/spacewalk"
 
-func DumpPackageFiles(db *sql.DB, schemaMetadata map[string]schemareader.Table, data dumper.DataDumper, outputFolder string) {
+// FileOptions control how the package files are exported
+type FileOptions struct {
+	// ReuseVerified keeps package files already in the output folder when their checksum matches the database
+	ReuseVerified bool
+	// Workers is the number of package files copied in parallel
+	Workers int
+	// LinkMode is how the files are written to the output folder, see dumper.ExportFile
+	LinkMode string
+	// PayloadList records the package files instead of copying them, when set
+	PayloadList *payload.List
+	// TargetInventory lists the package files the target server has, they are not exported
+	TargetInventory inventory.Inventory
+}
+
+// DumpPackageFiles copies the files of the exported packages into the output folder.
+// Files are copied in parallel, the export fails after all files were processed when any of them could not be copied.
+func DumpPackageFiles(db *sql.DB, schemaMetadata map[string]schemareader.Table, data dumper.DataDumper, outputFolder string,
+	options FileOptions) {
 
 	packageKeysData := data.TableData["rhnpackage"]
 	table := schemaMetadata[packageKeysData.TableName]
 	pathIndex := table.ColumnIndexes["path"]
+	checksumIndex := table.ColumnIndexes["checksum_id"]
 
 	totalPackages := len(packageKeysData.Keys)
 	log.Debug().Msgf("Total package files to copy: %d", totalPackages)
 
-	exportedpackages := 0
-	processing := true
-
-	if log.Debug().Enabled() {
-		go func() {
-			count := 0
-			for {
-				if !processing {
-					break
-				}
-				time.Sleep(30 * time.Second)
-				log.Debug().Msgf("#count: %d -- #exportedPackageFiles: #%d of %d",
-					count, exportedpackages, totalPackages)
-				count++
+	var skipped atomic.Int64
+	pool := newCopyPool(options.Workers, totalPackages, func(file packageFile) (int64, error) {
+		if options.TargetInventory != nil || options.PayloadList != nil {
+			checksum, checksumType, err := packageChecksum(db, file.checksumId)
+			if err != nil {
+				return 0, err
 			}
-		}()
-	}
+			if options.TargetInventory.Has(file.relativePath, checksumType, checksum) {
+				log.Trace().Msgf("skipping package file %s, the target server has it", file.relativePath)
+				skipped.Add(1)
+				return 0, nil
+			}
+			if options.PayloadList != nil {
+				return 0, options.PayloadList.AddPackage(file.source, file.relativePath, checksumType, checksum)
+			}
+		}
+		if options.ReuseVerified && isPackageFileVerified(db, file.target, file.checksumId) {
+			log.Trace().Msgf("reusing verified package file %s", file.target)
+			return 0, nil
+		}
+		return dumper.ExportFile(file.source, file.target, options.LinkMode)
+	})
 
 	exportPoint := 0
 	batchSize := 500
@@ -54,15 +81,50 @@ func DumpPackageFiles(db *sql.DB, schemaMetadata map[string]schemareader.Table,
 		rows := dumper.GetRowsFromKeys(db, table, packageKeysData.Keys[exportPoint:upperLimit])
 		for _, rowPackage := range rows {
 			path := rowPackage[pathIndex]
-			source := fmt.Sprintf("%s/%s", serverDataFolder, path.Value)
-			target := fmt.Sprintf("%s/%s", outputFolder, path.Value)
-			_, error := dumper.Copy(source, target)
-			if error != nil {
-				log.Panic().Err(error).Msg("could not Copy File")
-			}
-			exportedpackages++
+			pool.add(packageFile{
+				source:       fmt.Sprintf("%s/%s", serverDataFolder, path.Value),
+				target:       fmt.Sprintf("%s/%s", outputFolder, path.Value),
+				relativePath: fmt.Sprintf("%s", path.Value),
+				checksumId:   rowPackage[checksumIndex].Value,
+			})
 		}
 		exportPoint = upperLimit
 	}
-	processing = false
+
+	failures := pool.wait()
+	if options.TargetInventory != nil {
+		log.Info().Msgf("%d of %d package files not exported, the target server has them", skipped.Load(), totalPackages)
+	}
+	if len(failures) > 0 {
+		for _, failure := range failures {
+			log.Error().Err(failure).Msg("could not copy package file")
+		}
+		log.Panic().Msgf("%d of %d package files could not be copied", len(failures), totalPackages)
+	}
+}
+
+// isPackageFileVerified checks if the package file was exported before and its checksum matches the database
+func isPackageFileVerified(db *sql.DB, file string, checksumId interface{}) bool {
+	if _, err := os.Stat(file); err != nil {
+		return false
+	}
+	checksum, checksumType, err := packageChecksum(db, checksumId)
+	if err != nil {
+		log.Warn().Err(err).Msgf("unable to read the checksum of package file %s", file)
+		return false
+	}
+	matches, err := utils.FileMatchesChecksum(file, checksum, checksumType)
+	if err != nil {
+		log.Warn().Err(err).Msgf("unable to verify package file %s", file)
+		return false
+	}
+	return matches
+}
+
+// packageChecksum reads the checksum of the package file and its type from the database
+func packageChecksum(db *sql.DB, checksumId interface{}) (string, string, error) {
+	var checksum, checksumType string
+	err := db.QueryRow("SELECT c.checksum, ct.label FROM rhnchecksum c "+
+		"JOIN rhnchecksumtype ct ON c.checksum_type_id = ct.id WHERE c.id = $1", checksumId).Scan(&checksum, &checksumType)
+	return checksum, checksumType, err
 }
@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux
+
+package dumper
+
+import (
+	"os"
+	"syscall"
+)
+
+// ficlone is the FICLONE ioctl request, see ioctl_ficlone(2)
+const ficlone = 0x40049409
+
+// reflink clones src into the new file dst, supported by btrfs and XFS
+func reflink(src string, dst string) error {
+	source, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	destination, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer destination.Close()
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, destination.Fd(), ficlone, source.Fd()); errno != 0 {
+		return &os.PathError{Op: "ioctl FICLONE", Path: dst, Err: errno}
+	}
+	return destination.Close()
+}
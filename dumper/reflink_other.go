@@ -0,0 +1,11 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux
+
+package dumper
+
+func reflink(src string, dst string) error {
+	return errReflinkNotSupported
+}
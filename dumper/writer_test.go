@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/uyuni-project/inter-server-sync/schemareader"
 	"github.com/uyuni-project/inter-server-sync/sqlUtil"
 	"github.com/uyuni-project/inter-server-sync/tests"
@@ -425,3 +426,77 @@ func createTestCase(graph TablesGraph, root string, options PrintSqlOptions) wri
 		options,
 	}
 }
+
+func TestGenerateRowUpdateStatement(t *testing.T) {
+	// 01 Arrange
+	table := schemareader.Table{
+		Name:                "susecontentenvironment",
+		MainUniqueIndexName: schemareader.VirtualIndexName,
+		UniqueIndexes: map[string]schemareader.UniqueIndex{
+			schemareader.VirtualIndexName: {Name: schemareader.VirtualIndexName, Columns: []string{"label", "description"}},
+		},
+	}
+	row := []sqlUtil.RowDataStructure{
+		{ColumnName: "id", ColumnType: "NUMERIC", Value: "1"},
+		{ColumnName: "label", ColumnType: "VARCHAR", Value: "dev"},
+		{ColumnName: "description", ColumnType: "VARCHAR", Value: nil},
+		{ColumnName: "next_env_id", ColumnType: "NUMERIC", Value: "2"},
+	}
+	expectedResult := "UPDATE susecontentenvironment SET next_env_id = 2 WHERE label = 'dev' AND description IS NULL;"
+
+	// 02 Act
+	result := GenerateRowUpdateStatement(nil, row, table, map[string]schemareader.Table{}, []string{"next_env_id"})
+
+	// 03 Assert
+	if strings.Compare(result, expectedResult) != 0 {
+		t.Errorf("Expected %s, but got %s", expectedResult, result)
+	}
+}
+
+func TestGenerateNaturalKeyRowStatements(t *testing.T) {
+	// 01 Arrange
+	cache = make(map[string]string)
+	repo := tests.CreateDataRepository()
+	regToken := schemareader.Table{
+		Name:           "rhnregtoken",
+		Columns:        []string{"id", "note"},
+		ColumnIndexes:  map[string]int{"id": 0, "note": 1},
+		PKColumns:      map[string]bool{"id": true},
+		PKSequence:     "rhn_reg_token_seq",
+		NaturalKeyFrom: &schemareader.NaturalKeyReference{Table: "rhnactivationkey", Column: "reg_token_id", Key: "token"},
+	}
+	activationKey := schemareader.Table{
+		Name:                "rhnactivationkey",
+		Columns:             []string{"token", "reg_token_id"},
+		ColumnIndexes:       map[string]int{"token": 0, "reg_token_id": 1},
+		MainUniqueIndexName: "rhn_act_key_token_uq",
+		UniqueIndexes:       map[string]schemareader.UniqueIndex{"rhn_act_key_token_uq": {Name: "rhn_act_key_token_uq", Columns: []string{"token"}}},
+		References:          []schemareader.Reference{{TableName: "rhnregtoken", ColumnMapping: map[string]string{"reg_token_id": "id"}}},
+	}
+	schemaMetadata := map[string]schemareader.Table{"rhnregtoken": regToken, "rhnactivationkey": activationKey}
+	repo.ExpectWithRecords("SELECT token FROM rhnactivationkey WHERE reg_token_id = $1;", sqlmock.NewRows([]string{"token"}).AddRow("1-key"), "5")
+
+	// 02 Act
+	regTokenResult := generateRowInsertStatement(repo.DB, []sqlUtil.RowDataStructure{
+		{ColumnName: "id", ColumnType: "NUMERIC", Value: "5"},
+		{ColumnName: "note", ColumnType: "VARCHAR", Value: "it's"},
+	}, regToken, schemaMetadata, []string{})
+	activationKeyResult := generateRowInsertStatement(repo.DB, []sqlUtil.RowDataStructure{
+		{ColumnName: "token", ColumnType: "VARCHAR", Value: "1-key"},
+		{ColumnName: "reg_token_id", ColumnType: "NUMERIC", Value: "5"},
+	}, activationKey, schemaMetadata, []string{})
+
+	// 03 Assert
+	expectedRegToken := "UPDATE rhnregtoken SET note = 'it''s' WHERE id = (SELECT reg_token_id FROM rhnactivationkey WHERE token = '1-key');\n" +
+		"INSERT INTO rhnregtoken (id, note)\tSELECT (SELECT nextval('rhn_reg_token_seq')),'it''s' WHERE NOT EXISTS (SELECT reg_token_id FROM rhnactivationkey WHERE token = '1-key');"
+	if regTokenResult != expectedRegToken {
+		t.Errorf("Expected %s, but got %s", expectedRegToken, regTokenResult)
+	}
+	expectedReference := "(SELECT COALESCE((SELECT reg_token_id FROM rhnactivationkey WHERE token = '1-key'), currval('rhn_reg_token_seq')))"
+	if !strings.Contains(activationKeyResult, expectedReference) {
+		t.Errorf("Expected reference %s in %s", expectedReference, activationKeyResult)
+	}
+	if err := repo.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package encryption
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/uyuni-project/inter-server-sync/utils"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// FileName describes how the export was encrypted, it is the only file of an encrypted export stored as plain text
+const FileName = "encryption.json"
+
+// FileExtension is appended to the name of every encrypted file
+const FileExtension = ".enc"
+
+// Encryption methods
+const (
+	MethodCertificate = "certificate"
+	MethodPassphrase  = "passphrase"
+)
+
+const (
+	keySize = 32
+	// pbkdf2Iterations are the iterations deriving the key from a passphrase, as recommended by OWASP for HMAC-SHA256
+	pbkdf2Iterations = 600000
+)
+
+// Header is stored in FileName. Files are encrypted by a random key, the key is either encrypted
+// by the public key of the recipient certificate or derived from a passphrase.
+type Header struct {
+	Version int    `json:"version"`
+	Method  string `json:"method"`
+	// Recipient is the SHA-256 fingerprint of the certificate, EncryptedKey is the file key encrypted by RSA-OAEP
+	Recipient    string `json:"recipient,omitempty"`
+	EncryptedKey []byte `json:"encryptedKey,omitempty"`
+	// Salt and Iterations of PBKDF2 deriving the file key from the passphrase
+	Salt       []byte `json:"salt,omitempty"`
+	Iterations int    `json:"iterations,omitempty"`
+}
+
+// NewCertificateKey creates a random key encrypted for the owner of the RSA certificate
+func NewCertificateKey(certFile string) (*Header, []byte, error) {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read certificate: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, nil, fmt.Errorf("no PEM encoded certificate found in %s", certFile)
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid certificate %s: %w", certFile, err)
+	}
+	publicKey, ok := certificate.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported type %T of public key in certificate %s, only RSA keys can be used for encryption",
+			certificate.PublicKey, certFile)
+	}
+	key, err := randomBytes(keySize)
+	if err != nil {
+		return nil, nil, err
+	}
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, key, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to encrypt the key for %s: %w", certFile, err)
+	}
+	header := &Header{
+		Version:      1,
+		Method:       MethodCertificate,
+		Recipient:    certificateFingerprint(certificate),
+		EncryptedKey: encryptedKey,
+	}
+	return header, key, nil
+}
+
+// NewPassphraseKey derives a key from the passphrase on the first line of passfile
+func NewPassphraseKey(passfile string) (*Header, []byte, error) {
+	salt, err := randomBytes(16)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := &Header{Version: 1, Method: MethodPassphrase, Salt: salt, Iterations: pbkdf2Iterations}
+	key, err := header.passphraseKey(passfile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, key, nil
+}
+
+// Key returns the file key. decryptKey is the private key of the recipient certificate,
+// or the passphrase file for exports encrypted with a passphrase. keyPassfile decrypts an encrypted private key.
+func (h *Header) Key(decryptKey string, keyPassfile string) ([]byte, error) {
+	switch h.Method {
+	case MethodPassphrase:
+		return h.passphraseKey(decryptKey)
+	case MethodCertificate:
+		signer, err := utils.ReadPrivateKey(decryptKey, keyPassfile)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, ok := signer.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("export is encrypted for an RSA key, %s is not an RSA private key", decryptKey)
+		}
+		key, err := rsa.DecryptOAEP(sha256.New(), nil, privateKey, h.EncryptedKey, nil)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decrypt the export key with %s, the export is encrypted for certificate %s",
+				decryptKey, h.Recipient)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported encryption method %s", h.Method)
+	}
+}
+
+func (h *Header) passphraseKey(passfile string) ([]byte, error) {
+	passphrase, err := utils.ReadPassphrase(passfile)
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("empty passphrase in %s", passfile)
+	}
+	return pbkdf2.Key(passphrase, h.Salt, h.Iterations, keySize, sha256.New), nil
+}
+
+// ReadHeader reads the encryption header of the export directory, it returns nil for unencrypted exports
+func ReadHeader(exportDir string) (*Header, error) {
+	data, err := os.ReadFile(path.Join(exportDir, FileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	header := &Header{}
+	if err := json.Unmarshal(data, header); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", FileName, err)
+	}
+	return header, nil
+}
+
+// EncryptDir replaces every file of the export directory by its encrypted version and writes the header
+func EncryptDir(exportDir string, header *Header, key []byte) error {
+	err := filepath.WalkDir(exportDir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		if err := EncryptFile(key, filePath, filePath+FileExtension); err != nil {
+			return err
+		}
+		return os.Remove(filePath)
+	})
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(header, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(exportDir, FileName), data, 0644)
+}
+
+// DecryptDir decrypts every encrypted file of the export directory into the staging directory
+func DecryptDir(exportDir string, stagingDir string, key []byte) error {
+	return filepath.WalkDir(exportDir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(filePath, FileExtension) {
+			return err
+		}
+		relativePath, err := filepath.Rel(exportDir, strings.TrimSuffix(filePath, FileExtension))
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(stagingDir, relativePath)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := DecryptFile(key, filePath, target); err != nil {
+			return fmt.Errorf("unable to decrypt %s: %w", relativePath, err)
+		}
+		return nil
+	})
+}
+
+func certificateFingerprint(certificate *x509.Certificate) string {
+	fingerprint := sha256.Sum256(certificate.Raw)
+	return hex.EncodeToString(fingerprint[:])
+}
+
+func randomBytes(size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestEncryptFile(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := randomBytes(keySize)
+	for _, size := range []int{0, 10, chunkSize, 2*chunkSize + 1} {
+		content := make([]byte, size)
+		rand.Read(content)
+		plain := path.Join(dir, "plain")
+		encrypted := path.Join(dir, "plain.enc")
+		decrypted := path.Join(dir, "decrypted")
+		os.WriteFile(plain, content, 0600)
+
+		if err := EncryptFile(key, plain, encrypted); err != nil {
+			t.Fatal(err)
+		}
+		if err := DecryptFile(key, encrypted, decrypted); err != nil {
+			t.Fatalf("decryption of %d bytes failed: %s", size, err)
+		}
+		if result, _ := os.ReadFile(decrypted); !bytes.Equal(result, content) {
+			t.Errorf("decrypted content of %d bytes differs", size)
+		}
+	}
+
+	// dropping the last chunk needs to be detected
+	data, _ := os.ReadFile(path.Join(dir, "plain.enc"))
+	os.WriteFile(path.Join(dir, "truncated.enc"), data[:prefixSize+2*(chunkSize+16)], 0600)
+	if err := DecryptFile(key, path.Join(dir, "truncated.enc"), path.Join(dir, "decrypted")); err != errCorrupted {
+		t.Errorf("expected truncated file to be rejected, got %v", err)
+	}
+	data[prefixSize+10] ^= 1
+	os.WriteFile(path.Join(dir, "modified.enc"), data, 0600)
+	if err := DecryptFile(key, path.Join(dir, "modified.enc"), path.Join(dir, "decrypted")); err != errCorrupted {
+		t.Errorf("expected modified file to be rejected, got %v", err)
+	}
+}
+
+func TestCertificateKey(t *testing.T) {
+	dir := t.TempDir()
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "target"},
+		NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	der, _ := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	certFile := path.Join(dir, "target.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	keyFile := path.Join(dir, "target.key")
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}), 0600)
+
+	header, key, err := NewCertificateKey(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decryptedKey, err := header.Key(keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, decryptedKey) {
+		t.Error("decrypted key differs")
+	}
+}
+
+func TestEncryptDir(t *testing.T) {
+	exportDir := t.TempDir()
+	stagingDir := t.TempDir()
+	passfile := path.Join(t.TempDir(), "passfile")
+	os.WriteFile(passfile, []byte("secret\n"), 0600)
+	os.MkdirAll(path.Join(exportDir, "packages"), 0755)
+	os.WriteFile(path.Join(exportDir, "sql_statements.sql.gz"), []byte("sql"), 0600)
+	os.WriteFile(path.Join(exportDir, "packages", "test.rpm"), []byte("package"), 0600)
+
+	header, key, err := NewPassphraseKey(passfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := EncryptDir(exportDir, header, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path.Join(exportDir, "packages", "test.rpm")); !os.IsNotExist(err) {
+		t.Error("plain text file was not removed")
+	}
+
+	readHeader, err := ReadHeader(exportDir)
+	if err != nil || readHeader.Method != MethodPassphrase {
+		t.Fatalf("unexpected header %v: %v", readHeader, err)
+	}
+	decryptedKey, err := readHeader.Key(passfile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := DecryptDir(exportDir, stagingDir, decryptedKey); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(path.Join(stagingDir, "packages", "test.rpm")); string(content) != "package" {
+		t.Errorf("unexpected decrypted content %q", content)
+	}
+}
@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package encryption
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+// Encrypted files start with a random nonce prefix followed by AES-256-GCM sealed chunks.
+// The nonce of a chunk is the prefix, the chunk counter and a flag marking the last chunk,
+// so reordered, duplicated or truncated chunks fail the authentication.
+const (
+	chunkSize   = 64 * 1024
+	prefixSize  = 7
+	lastChunk   = 1
+	otherChunks = 0
+)
+
+var errCorrupted = errors.New("encrypted file is corrupted or the key is wrong")
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(prefix []byte, counter uint32, flag byte) []byte {
+	nonce := make([]byte, 0, prefixSize+5)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	return append(nonce, flag)
+}
+
+// EncryptFile writes the encrypted content of source to target
+func EncryptFile(key []byte, source string, target string) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	prefix, err := randomBytes(prefixSize)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(out)
+	writer.Write(prefix)
+	reader := bufio.NewReaderSize(in, chunkSize)
+	chunk := make([]byte, chunkSize)
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(reader, chunk)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		flag := byte(otherChunks)
+		if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+			flag = lastChunk
+		}
+		if _, err := writer.Write(aead.Seal(nil, chunkNonce(prefix, counter, flag), chunk[:n], nil)); err != nil {
+			return err
+		}
+		if flag == lastChunk {
+			break
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// DecryptFile writes the decrypted content of source to target, it fails if the content was modified
+func DecryptFile(key []byte, source string, target string) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	reader := bufio.NewReaderSize(in, chunkSize+aead.Overhead())
+	prefix := make([]byte, prefixSize)
+	if _, err := io.ReadFull(reader, prefix); err != nil {
+		return errCorrupted
+	}
+	writer := bufio.NewWriter(out)
+	chunk := make([]byte, chunkSize+aead.Overhead())
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(reader, chunk)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		flag := byte(otherChunks)
+		if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+			flag = lastChunk
+		}
+		plain, err := aead.Open(chunk[:0], chunkNonce(prefix, counter, flag), chunk[:n], nil)
+		if err != nil {
+			return errCorrupted
+		}
+		if _, err := writer.Write(plain); err != nil {
+			return err
+		}
+		if flag == lastChunk {
+			break
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entityDumper
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/dumper"
+	"github.com/uyuni-project/inter-server-sync/schemareader"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+// tablesToClean_activationKeys represents Tables which needs to be cleaned in case on client side there is a record that doesn't exist anymore on master side
+var tablesToClean_activationKeys = []string{
+	"rhnregtokenchannels",
+	"rhnregtokengroups",
+	"rhnregtokenentitlement",
+}
+
+// ActivationKeyTableNames is the list of names of tables relevant for exporting activation keys
+func ActivationKeyTableNames() []string {
+	return []string{
+		"rhnactivationkey",
+		"rhnregtoken",
+		"rhnregtokenchannels",    // clean
+		"rhnregtokengroups",      // clean
+		"rhnregtokenentitlement", // clean
+		"rhnservergroup",
+	}
+}
+
+var activationKeySql = "select token from rhnactivationkey " +
+	"where token = $1"
+
+func loadActivationKeysToProcess(db *sql.DB, options DumperOptions) []string {
+	log.Trace().Msg("Loading activation key list")
+	keys := newLabelSet()
+	for _, key := range options.ActivationKeys {
+		if !keys.contains(key) {
+			dbKey := sqlUtil.ExecuteQueryWithResults(db, activationKeySql, key)
+			if len(dbKey) == 0 {
+				log.Fatal().Msgf("Activation key not found: %s", key)
+			}
+			keys.add(key)
+		}
+	}
+
+	if options.ImageActivationKeys {
+		for _, key := range loadImageProfileActivationKeys(db, options) {
+			keys.add(key)
+		}
+	}
+	log.Debug().Msgf("Activation keys to export: %s", strings.Join(keys.labels, ","))
+	return keys.labels
+}
+
+// loadImageProfileActivationKeys returns the activation keys referenced by the image profiles
+// which are exported with the same options
+func loadImageProfileActivationKeys(db *sql.DB, options DumperOptions) []string {
+	imageTypes := make([]string, 0)
+	if options.OSImages {
+		imageTypes = append(imageTypes, "'kiwi'")
+	}
+	if options.Containers {
+		imageTypes = append(imageTypes, "'dockerfile'")
+	}
+	if len(imageTypes) == 0 {
+		log.Warn().Msg("No image export requested, no activation keys will be exported from image profiles")
+		return []string{}
+	}
+
+	sqlForProfileKeys := fmt.Sprintf("SELECT DISTINCT ak.token FROM suseimageprofile AS ip "+
+		"JOIN rhnactivationkey AS ak ON ak.reg_token_id = ip.token_id WHERE ip.image_type IN (%s)", strings.Join(imageTypes, ", "))
+	if len(options.Orgs) > 0 {
+		orgs := make([]string, 0)
+		for _, org := range options.Orgs {
+			orgs = append(orgs, fmt.Sprint(org))
+		}
+		sqlForProfileKeys = fmt.Sprintf("%s AND ip.org_id IN (%s)", sqlForProfileKeys, strings.Join(orgs, ", "))
+	}
+	if options.StartingDate != "" {
+		sqlForProfileKeys = fmt.Sprintf("%s AND ip.modified > '%s'::timestamp", sqlForProfileKeys, options.StartingDate)
+	}
+
+	keys := make([]string, 0)
+	for _, row := range sqlUtil.ExecuteQueryWithResults(db, sqlForProfileKeys) {
+		keys = append(keys, fmt.Sprintf("%v", row[0].Value))
+	}
+	return keys
+}
+
+func processActivationKeys(db *sql.DB, writer *bufio.Writer, options DumperOptions) {
+
+	keys := loadActivationKeysToProcess(db, options)
+	log.Info().Msg(fmt.Sprintf("%d activation keys to process", len(keys)))
+	if len(keys) == 0 {
+		return
+	}
+	schemaMetadata := readTablesSchema(db, ActivationKeyTableNames(), options)
+	log.Debug().Msg("activation key schema metadata loaded")
+
+	keyLabels, err := os.Create(options.GetOutputFolderAbsPath() + "/exportedActivationKeys.txt")
+	if err != nil {
+		log.Panic().Err(err).Msg("error creating exportedActivationKeys file")
+	}
+	defer keyLabels.Close()
+	bufferWriterKeys := bufio.NewWriter(keyLabels)
+	defer bufferWriterKeys.Flush()
+
+	writer.WriteString("-- Activation keys\n")
+	count := 0
+	for _, key := range keys {
+		count++
+		log.Debug().Msg(fmt.Sprintf("Processing activation key [%d/%d] %s", count, len(keys), key))
+		processActivationKey(db, writer, key, schemaMetadata, options)
+		writer.Flush()
+		bufferWriterKeys.WriteString(fmt.Sprintf("%s\n", key))
+	}
+}
+
+func processActivationKey(db *sql.DB, writer *bufio.Writer, key string,
+	schemaMetadata map[string]schemareader.Table, options DumperOptions) {
+	whereFilter := fmt.Sprintf("token = '%s'", key)
+	tableData := dumper.DataCrawler(db, schemaMetadata, schemaMetadata["rhnactivationkey"], whereFilter, options.StartingDate)
+	log.Debug().Msg("finished table data crawler")
+
+	cleanWhereClause := fmt.Sprintf(`WHERE rhnactivationkey.token = '%s'`, key)
+	printOptions := dumper.PrintSqlOptions{
+		TablesToClean:            tablesToClean_activationKeys,
+		CleanWhereClause:         cleanWhereClause,
+		OnlyIfParentExistsTables: onlyIfParentExistsTables,
+	}
+
+	dumper.PrintTableDataOrdered(db, writer, schemaMetadata, schemaMetadata["rhnactivationkey"],
+		tableData, printOptions)
+	log.Debug().Msg("activation key export finished")
+}
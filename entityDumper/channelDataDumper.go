@@ -28,7 +28,8 @@ var tablesToClean = []string{"rhnreleasechannelmap", "rhndistchannelmap", "rhnch
 	"rhnerratabuglist", "rhnerratacve",
 	"rhnerratakeyword",
 	"susemddata", "suseproductchannel", "rhnchannelcloned",
-	"rhnpackageextratag"}
+	"rhnpackageextratag",
+	"rhnchannelcontentsource"}
 
 // onlyIfParentExistsTables represents Tables for which only records needs to be insterted only if parent record exists
 var onlyIfParentExistsTables = []string{"rhnchannelcloned", "rhnerratacloned", "suseproductchannel"}
@@ -88,6 +89,16 @@ func SoftwareChannelTableNames() []string {
 	}
 }
 
+// RepositoryTableNames is the list of names of tables relevant for exporting repositories attached to software channels.
+// Crypto keys used by the repositories are referenced by their description, they are exported with the crypto keys.
+func RepositoryTableNames() []string {
+	return []string{
+		"rhncontentsource",
+		"rhnchannelcontentsource", // clean
+		"rhncontentssl",
+	}
+}
+
 func ProductsTableNames() []string {
 	return []string{
 		// product data tables
@@ -162,9 +173,9 @@ func loadChannelsToProcess(db *sql.DB, options DumperOptions) []string {
 	return channels.channels
 }
 
-func processAndInsertProducts(db *sql.DB, writer *bufio.Writer) {
+func processAndInsertProducts(db *sql.DB, writer *bufio.Writer, options DumperOptions) {
 	log.Trace().Msg("Processing product tables")
-	schemaMetadata := schemareader.ReadTablesSchema(db, ProductsTableNames())
+	schemaMetadata := readTablesSchema(db, ProductsTableNames(), options)
 	startingTables := []schemareader.Table{schemaMetadata["suseproducts"]}
 
 	var whereFilterClause = func(table schemareader.Table) string {
@@ -181,9 +192,8 @@ func processAndInsertProducts(db *sql.DB, writer *bufio.Writer) {
 	log.Debug().Msg("products export done")
 }
 
-func processAndInsertChannels(db *sql.DB, writer *bufio.Writer, options DumperOptions) {
+func processAndInsertChannels(db *sql.DB, writer *bufio.Writer, channels []string, options DumperOptions) {
 
-	channels := loadChannelsToProcess(db, options)
 	log.Info().Msg(fmt.Sprintf("%d channels to process", len(channels)))
 
 	channelTables := SoftwareChannelTableNames()
@@ -192,8 +202,11 @@ func processAndInsertChannels(db *sql.DB, writer *bufio.Writer, options DumperOp
 			"rhnpackagechangelogdata",
 			"rhnpackagechangelogrec")
 	}
+	if options.WithRepositories {
+		channelTables = append(channelTables, RepositoryTableNames()...)
+	}
 
-	schemaMetadata := schemareader.ReadTablesSchema(db, channelTables)
+	schemaMetadata := readTablesSchema(db, channelTables, options)
 	log.Debug().Msg("channel schema metadata loaded")
 
 	fileChannels, err := os.Create(options.GetOutputFolderAbsPath() + "/exportedChannels.txt")
@@ -209,8 +222,11 @@ func processAndInsertChannels(db *sql.DB, writer *bufio.Writer, options DumperOp
 	for _, channelLabel := range channels {
 		count++
 		log.Info().Msg(fmt.Sprintf("Processing channel [%d/%d] %s", count, len(channels), channelLabel))
-		processChannel(db, writer, channelLabel, schemaMetadata, options)
-		writer.Flush()
+		options.checkpoint.segment("channel:"+channelLabel, options, func() {
+			writer.WriteString(sqlUtil.EntityBeginMarker(sqlUtil.EntityChannel, channelLabel))
+			processChannel(db, writer, channelLabel, schemaMetadata, options)
+			options.checkpoint.endEntity(writer, sqlUtil.EntityChannel, channelLabel)
+		})
 		bufferWriterChannels.WriteString(fmt.Sprintf("%s\n", channelLabel))
 	}
 }
@@ -218,7 +234,7 @@ func processAndInsertChannels(db *sql.DB, writer *bufio.Writer, options DumperOp
 func processChannel(db *sql.DB, writer *bufio.Writer, channelLabel string,
 	schemaMetadata map[string]schemareader.Table, options DumperOptions) {
 	whereFilter := fmt.Sprintf("label = '%s'", channelLabel)
-	tableData := dumper.DataCrawler(db, schemaMetadata, schemaMetadata["rhnchannel"], whereFilter, options.StartingDate)
+	tableData := dumper.DataCrawler(db, schemaMetadata, schemaMetadata["rhnchannel"], whereFilter, options.channelStartingDate(channelLabel))
 
 	if log.Debug().Enabled() {
 		totalRows := 0
@@ -244,7 +260,7 @@ func processChannel(db *sql.DB, writer *bufio.Writer, channelLabel string,
 
 	if !options.MetadataOnly {
 		log.Debug().Msg("dumping all package files")
-		packageDumper.DumpPackageFiles(db, schemaMetadata, tableData, options.GetOutputFolderAbsPath())
+		packageDumper.DumpPackageFiles(db, schemaMetadata, tableData, options.GetOutputFolderAbsPath(), options.packageFileOptions())
 	}
 	log.Debug().Msg("channel export finished")
 
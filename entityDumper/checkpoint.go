@@ -0,0 +1,208 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entityDumper
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/schemareader"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+// CheckpointFileName is the file recording the progress of an export, it is removed once the export is finished
+const CheckpointFileName = "export_checkpoint.json"
+
+// checkpointSegment is a finished part of the SQL file. Offset is the size of the SQL file after the segment.
+type checkpointSegment struct {
+	Name   string `json:"name"`
+	Offset int64  `json:"offset"`
+}
+
+// exportCheckpoint writes the SQL file as a sequence of gzip members, one per segment.
+// After every segment the checkpoint file records the segment and the SQL file size,
+// so an interrupted export can cut the SQL file after the last finished segment and continue from there.
+type exportCheckpoint struct {
+	OptionsDigest string                         `json:"optionsDigest"`
+	ExportStarted string                         `json:"exportStarted"`
+	Segments      []checkpointSegment            `json:"segments"`
+	Schema        schemareader.SchemaFingerprint `json:"schema"`
+	// Entities are the labels of the exported entities by kind
+	Entities map[string][]string `json:"entities"`
+
+	fileName   string
+	completed  map[string]bool
+	sqlFile    *os.File
+	gzipWriter *gzip.Writer
+	writer     *bufio.Writer
+	// entities of the running segment, recorded once the segment is complete
+	pendingEntities []sqlUtil.EntityMarker
+}
+
+// optionsDigest identifies the exported content, a resumed export needs to use the same options
+func optionsDigest(options DumperOptions) string {
+	options.Resume = false
+	options.CopyWorkers = 0
+	options.LinkMode = ""
+	options.SignKey = ""
+	options.PassFile = ""
+	data, err := json.Marshal(options)
+	if err != nil {
+		log.Panic().Err(err).Msg("error serializing export options")
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// newExportCheckpoint starts a new export in the empty output folder
+func newExportCheckpoint(outputFolderAbs string, options DumperOptions) *exportCheckpoint {
+	checkpoint := &exportCheckpoint{
+		OptionsDigest: optionsDigest(options),
+		Segments:      make([]checkpointSegment, 0),
+		Schema:        make(schemareader.SchemaFingerprint),
+		Entities:      make(map[string][]string),
+		fileName:      path.Join(outputFolderAbs, CheckpointFileName),
+		completed:     make(map[string]bool),
+	}
+	file, err := os.OpenFile(path.Join(outputFolderAbs, sqlUtil.SqlFileName), os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		log.Panic().Err(err).Msg("error creating sql file")
+	}
+	checkpoint.open(file)
+	checkpoint.save()
+	return checkpoint
+}
+
+// resumeExportCheckpoint continues the export recorded in the checkpoint file of the output folder
+func resumeExportCheckpoint(outputFolderAbs string, options DumperOptions) *exportCheckpoint {
+	fileName := path.Join(outputFolderAbs, CheckpointFileName)
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("No export to resume in %s", outputFolderAbs)
+	}
+	checkpoint := &exportCheckpoint{}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid export checkpoint %s", fileName)
+	}
+	if checkpoint.OptionsDigest != optionsDigest(options) {
+		log.Fatal().Msg("The export to resume was started with different options, please use the same options")
+	}
+	checkpoint.fileName = fileName
+	checkpoint.completed = make(map[string]bool)
+	if checkpoint.Schema == nil {
+		checkpoint.Schema = make(schemareader.SchemaFingerprint)
+	}
+	if checkpoint.Entities == nil {
+		checkpoint.Entities = make(map[string][]string)
+	}
+	offset := int64(0)
+	for _, segment := range checkpoint.Segments {
+		checkpoint.completed[segment.Name] = true
+		offset = segment.Offset
+	}
+
+	file, err := os.OpenFile(path.Join(outputFolderAbs, sqlUtil.SqlFileName), os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		log.Panic().Err(err).Msg("error opening sql file")
+	}
+	// statements of the interrupted segment are dropped
+	if err := file.Truncate(offset); err != nil {
+		log.Panic().Err(err).Msg("error truncating sql file")
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		log.Panic().Err(err).Msg("error seeking sql file")
+	}
+	log.Info().Msgf("Resuming export after %d finished segments", len(checkpoint.Segments))
+	checkpoint.open(file)
+	return checkpoint
+}
+
+func (c *exportCheckpoint) open(file *os.File) {
+	c.sqlFile = file
+	c.gzipWriter = gzip.NewWriter(file)
+	c.writer = bufio.NewWriterSize(c.gzipWriter, 32768)
+}
+
+func (c *exportCheckpoint) isCompleted(name string) bool {
+	return c.completed[name]
+}
+
+// segment runs the export of the segment unless it was finished by a previous run
+func (c *exportCheckpoint) segment(name string, options DumperOptions, export func()) {
+	if c.isCompleted(name) {
+		log.Info().Msgf("Skipping %s, it was exported before", name)
+		return
+	}
+	export()
+	c.complete(name, options)
+}
+
+// endEntity writes the end marker of the entity, the entity is recorded as exported together with its segment
+func (c *exportCheckpoint) endEntity(writer *bufio.Writer, kind string, label string) {
+	writer.WriteString(sqlUtil.EntityEndMarker(kind, label))
+	c.pendingEntities = append(c.pendingEntities, sqlUtil.EntityMarker{Kind: kind, Label: label})
+}
+
+// complete ends the gzip member of the segment and records it in the checkpoint file
+func (c *exportCheckpoint) complete(name string, options DumperOptions) {
+	if err := c.writer.Flush(); err != nil {
+		log.Panic().Err(err).Msg("error writing sql file")
+	}
+	if err := c.gzipWriter.Close(); err != nil {
+		log.Panic().Err(err).Msg("error writing sql file")
+	}
+	offset, err := c.sqlFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		log.Panic().Err(err).Msg("error reading sql file position")
+	}
+	if err := c.sqlFile.Sync(); err != nil {
+		log.Panic().Err(err).Msg("error writing sql file")
+	}
+	for tableName, table := range schemareader.Fingerprint(options.exportedSchema) {
+		c.Schema[tableName] = table
+	}
+	for _, entity := range c.pendingEntities {
+		c.Entities[entity.Kind] = append(c.Entities[entity.Kind], entity.Label)
+	}
+	c.pendingEntities = nil
+	c.Segments = append(c.Segments, checkpointSegment{Name: name, Offset: offset})
+	c.completed[name] = true
+	c.save()
+
+	c.gzipWriter.Reset(c.sqlFile)
+	c.writer.Reset(c.gzipWriter)
+}
+
+// save replaces the checkpoint file, a crash leaves either the old or the new checkpoint
+func (c *exportCheckpoint) save() {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		log.Panic().Err(err).Msg("error serializing export checkpoint")
+	}
+	tmpFileName := c.fileName + ".tmp"
+	if err := os.WriteFile(tmpFileName, data, 0600); err != nil {
+		log.Panic().Err(err).Msg("error writing export checkpoint")
+	}
+	if err := os.Rename(tmpFileName, c.fileName); err != nil {
+		log.Panic().Err(err).Msg("error writing export checkpoint")
+	}
+}
+
+// finish closes the SQL file and signs it. The checkpoint file is removed, the export cannot be resumed anymore.
+func (c *exportCheckpoint) finish(options DumperOptions) {
+	// all data were written by the last segment, the empty gzip member is not needed
+	if err := closeAndSign(c.sqlFile, options.SignKey, options.PassFile); err != nil {
+		log.Error().Err(err).Msg("error finishing sql file")
+	}
+	if err := os.Remove(c.fileName); err != nil {
+		log.Warn().Err(err).Msgf("unable to remove export checkpoint %s", c.fileName)
+	}
+}
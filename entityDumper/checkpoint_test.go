@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entityDumper
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/uyuni-project/inter-server-sync/schemareader"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+func TestExportCheckpointResume(t *testing.T) {
+	dir := t.TempDir()
+	options := DumperOptions{OutputFolder: dir, ChannelLabels: []string{"a", "b"},
+		exportedSchema: make(map[string]schemareader.Table)}
+
+	checkpoint := newExportCheckpoint(dir, options)
+	checkpoint.segment("channel:a", options, func() {
+		checkpoint.writer.WriteString("a;\n")
+		checkpoint.pendingEntities = append(checkpoint.pendingEntities, sqlUtil.EntityMarker{Kind: sqlUtil.EntityChannel, Label: "a"})
+	})
+	// interrupted export, the statements of b are partially written
+	checkpoint.writer.WriteString("b-partial;\n")
+	checkpoint.pendingEntities = append(checkpoint.pendingEntities, sqlUtil.EntityMarker{Kind: sqlUtil.EntityChannel, Label: "b"})
+	checkpoint.writer.Flush()
+	checkpoint.gzipWriter.Flush()
+	checkpoint.sqlFile.Close()
+
+	options.Resume = true
+	resumed := resumeExportCheckpoint(dir, options)
+	if !resumed.isCompleted("channel:a") || resumed.isCompleted("channel:b") {
+		t.Fatalf("unexpected completed segments: %v", resumed.Segments)
+	}
+	if entities := resumed.Entities[sqlUtil.EntityChannel]; len(entities) != 1 || entities[0] != "a" {
+		t.Errorf("unexpected entities of the interrupted export: %v", resumed.Entities)
+	}
+	resumed.segment("channel:a", options, func() { t.Error("completed segment exported again") })
+	resumed.segment("channel:b", options, func() { resumed.writer.WriteString("b;\n") })
+	resumed.sqlFile.Close()
+
+	file, err := os.Open(path.Join(dir, sqlUtil.SqlFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "a;\nb;\n" {
+		t.Errorf("unexpected sql file content: %q", content)
+	}
+}
@@ -49,7 +49,7 @@ func processConfigs(db *sql.DB, writer *bufio.Writer, options DumperOptions) {
 
 	configs := loadConfigsToProcess(db, options)
 	log.Info().Msg(fmt.Sprintf("%d configuration channels to process", len(configs)))
-	schemaMetadata := schemareader.ReadTablesSchema(db, ConfigTableNames())
+	schemaMetadata := readTablesSchema(db, ConfigTableNames(), options)
 	log.Debug().Msg("channel schema metadata loaded")
 	configLabels, err := os.Create(options.GetOutputFolderAbsPath() + "/exportedConfigs.txt")
 	if err != nil {
@@ -63,8 +63,11 @@ func processConfigs(db *sql.DB, writer *bufio.Writer, options DumperOptions) {
 	for _, l := range configs {
 		count++
 		log.Debug().Msg(fmt.Sprintf("Processing channel [%d/%d] %s", count, len(configs), l))
-		processConfigChannel(db, writer, l, schemaMetadata, options)
-		writer.Flush()
+		options.checkpoint.segment("configChannel:"+l, options, func() {
+			writer.WriteString(sqlUtil.EntityBeginMarker(sqlUtil.EntityConfigChannel, l))
+			processConfigChannel(db, writer, l, schemaMetadata, options)
+			options.checkpoint.endEntity(writer, sqlUtil.EntityConfigChannel, l)
+		})
 		bufferWriterChannels.WriteString(fmt.Sprintf("%s\n", l))
 	}
 
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entityDumper
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/dumper"
+	"github.com/uyuni-project/inter-server-sync/schemareader"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+// tablesToClean_contentProjects represents Tables which needs to be cleaned in case on client side there is a record that doesn't exist anymore on master side
+var tablesToClean_contentProjects = []string{
+	"susecontentprojectsource",
+	"susecontentprojectfilter",
+	"susecontentenvironmenttarget",
+}
+
+// ContentProjectTableNames is the list of names of tables relevant for exporting content lifecycle management projects
+func ContentProjectTableNames() []string {
+	return []string{
+		"susecontentproject",
+		"susecontentenvironment",
+		"susecontentenvironmenttarget", // clean
+		"susecontentprojectsource",     // clean
+		"susecontentprojectfilter",     // clean
+		"susecontentfilter",
+	}
+}
+
+var contentProjectSql = "select label from susecontentproject " +
+	"where label = $1"
+
+// built channels of all environments of the project
+var contentProjectChannelsSql = "select distinct c.label from susecontentenvironmenttarget t " +
+	"join susecontentenvironment e on t.env_id = e.id " +
+	"join susecontentproject p on e.project_id = p.id " +
+	"join rhnchannel c on t.channel_id = c.id " +
+	"where p.label = $1 order by c.label"
+
+func loadContentProjectsToProcess(db *sql.DB, options DumperOptions) []string {
+	log.Trace().Msg("Loading content project list")
+	projects := newLabelSet()
+	for _, project := range options.ContentProjects {
+		if !projects.contains(project) {
+			dbProject := sqlUtil.ExecuteQueryWithResults(db, contentProjectSql, project)
+			if len(dbProject) == 0 {
+				log.Fatal().Msgf("Content project not found: %s", project)
+			}
+			projects.add(project)
+		}
+	}
+	log.Debug().Msgf("Content projects to export: %s", strings.Join(projects.labels, ","))
+	return projects.labels
+}
+
+// loadContentProjectChannels returns the channels built by the environments of the content projects
+func loadContentProjectChannels(db *sql.DB, options DumperOptions) []string {
+	channels := make([]string, 0)
+	for _, project := range loadContentProjectsToProcess(db, options) {
+		for _, row := range sqlUtil.ExecuteQueryWithResults(db, contentProjectChannelsSql, project) {
+			channels = append(channels, fmt.Sprintf("%v", row[0].Value))
+		}
+	}
+	log.Debug().Msgf("Content project channels to export: %s", strings.Join(channels, ","))
+	return channels
+}
+
+func processContentProjects(db *sql.DB, writer *bufio.Writer, options DumperOptions) {
+
+	projects := loadContentProjectsToProcess(db, options)
+	log.Info().Msg(fmt.Sprintf("%d content projects to process", len(projects)))
+	if len(projects) == 0 {
+		return
+	}
+	schemaMetadata := readTablesSchema(db, ContentProjectTableNames(), options)
+	log.Debug().Msg("content project schema metadata loaded")
+
+	projectLabels, err := os.Create(options.GetOutputFolderAbsPath() + "/exportedContentProjects.txt")
+	if err != nil {
+		log.Panic().Err(err).Msg("error creating exportedContentProjects file")
+	}
+	defer projectLabels.Close()
+	bufferWriterProjects := bufio.NewWriter(projectLabels)
+	defer bufferWriterProjects.Flush()
+
+	writer.WriteString("-- Content Lifecycle Management projects\n")
+	count := 0
+	for _, project := range projects {
+		count++
+		log.Info().Msg(fmt.Sprintf("Processing content project [%d/%d] %s", count, len(projects), project))
+		processContentProject(db, writer, project, schemaMetadata, options)
+		writer.Flush()
+		bufferWriterProjects.WriteString(fmt.Sprintf("%s\n", project))
+	}
+}
+
+func processContentProject(db *sql.DB, writer *bufio.Writer, projectLabel string,
+	schemaMetadata map[string]schemareader.Table, options DumperOptions) {
+	whereFilter := fmt.Sprintf("label = '%s'", projectLabel)
+	tableData := dumper.DataCrawler(db, schemaMetadata, schemaMetadata["susecontentproject"], whereFilter, options.StartingDate)
+	log.Debug().Msg("finished table data crawler")
+
+	cleanWhereClause := fmt.Sprintf(`WHERE susecontentproject.label = '%s'`, projectLabel)
+	printOptions := dumper.PrintSqlOptions{
+		TablesToClean:            tablesToClean_contentProjects,
+		CleanWhereClause:         cleanWhereClause,
+		OnlyIfParentExistsTables: onlyIfParentExistsTables,
+		PostOrderCallback:        createContentProjectPostOrderCallback(),
+	}
+
+	dumper.PrintTableDataOrdered(db, writer, schemaMetadata, schemaMetadata["susecontentproject"],
+		tableData, printOptions)
+	log.Debug().Msg("content project export finished")
+}
+
+// createContentProjectPostOrderCallback links the environments once all of them are created.
+// Projects point to their first environment and each environment points to the next one.
+func createContentProjectPostOrderCallback() dumper.Callback {
+	linkColumns := map[string][]string{
+		"susecontentproject":     {"first_env_id"},
+		"susecontentenvironment": {"next_env_id"},
+	}
+	return func(db *sql.DB, writer *bufio.Writer, schemaMetadata map[string]schemareader.Table,
+		table schemareader.Table, data dumper.DataDumper) {
+
+		columns, linked := linkColumns[table.Name]
+		tableData, dataOK := data.TableData[table.Name]
+		if !linked || !dataOK {
+			return
+		}
+		exportPoint := 0
+		batch := 100
+		for len(tableData.Keys) > exportPoint {
+			upperLimit := exportPoint + batch
+			if upperLimit > len(tableData.Keys) {
+				upperLimit = len(tableData.Keys)
+			}
+			rows := dumper.GetRowsFromKeys(db, table, tableData.Keys[exportPoint:upperLimit])
+			for _, rowValue := range rows {
+				updateString := dumper.GenerateRowUpdateStatement(db, rowValue, table, schemaMetadata, columns)
+				writer.WriteString(updateString + "\n")
+			}
+			exportPoint = upperLimit
+		}
+	}
+}
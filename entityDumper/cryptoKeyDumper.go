@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entityDumper
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/dumper"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+// CryptoKeyTableNames is the list of names of tables relevant for exporting GPG and SSL crypto keys
+func CryptoKeyTableNames() []string {
+	return []string{
+		"rhncryptokey",
+	}
+}
+
+type cryptoKeyToProcess struct {
+	id          string
+	description string
+}
+
+var cryptoKeySql = "select id, description from rhncryptokey " +
+	"where description = $1"
+
+// certificates used by the repositories of the channel
+var channelCryptoKeysSql = "select distinct k.id, k.description from rhncryptokey k " +
+	"join rhncontentssl s on k.id in (s.ssl_ca_cert_id, s.ssl_client_cert_id) " +
+	"join rhnchannelcontentsource cs on cs.source_id = s.content_source_id " +
+	"join rhnchannel c on c.id = cs.channel_id " +
+	"where c.label = $1"
+
+// SSL client private keys used by the repositories of the channel, they are exported only when requested by description
+var channelClientKeysSql = "select distinct k.id, k.description from rhncryptokey k " +
+	"join rhncontentssl s on k.id = s.ssl_client_key_id " +
+	"join rhnchannelcontentsource cs on cs.source_id = s.content_source_id " +
+	"join rhnchannel c on c.id = cs.channel_id " +
+	"where c.label = $1"
+
+func loadCryptoKeysToProcess(db *sql.DB, options DumperOptions) []cryptoKeyToProcess {
+	log.Trace().Msg("Loading crypto key list")
+	keysMap := make(map[string]bool)
+	keys := make([]cryptoKeyToProcess, 0)
+	addKeys := func(rows [][]sqlUtil.RowDataStructure) {
+		for _, row := range rows {
+			key := cryptoKeyToProcess{fmt.Sprintf("%v", row[0].Value), fmt.Sprintf("%v", row[1].Value)}
+			if _, ok := keysMap[key.id]; !ok {
+				keysMap[key.id] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	sqlForKeys := cryptoKeySql
+	if len(options.Orgs) > 0 {
+		orgs := make([]string, 0)
+		for _, org := range options.Orgs {
+			orgs = append(orgs, fmt.Sprint(org))
+		}
+		sqlForKeys = fmt.Sprintf("%s and org_id in (%s)", cryptoKeySql, strings.Join(orgs, ", "))
+	}
+	for _, description := range options.CryptoKeys {
+		dbKeys := sqlUtil.ExecuteQueryWithResults(db, sqlForKeys, description)
+		if len(dbKeys) == 0 {
+			log.Fatal().Msgf("Crypto key not found: %s", description)
+		}
+		addKeys(dbKeys)
+	}
+
+	if options.ReferencedCryptoKeys {
+		if !options.WithRepositories && len(options.AutoinstallProfiles) == 0 {
+			log.Warn().Msg("Neither repositories nor autoinstallation profiles are exported, no referenced crypto keys will be exported")
+		}
+		if options.WithRepositories {
+			for _, channel := range loadChannelsToProcess(db, options) {
+				addKeys(sqlUtil.ExecuteQueryWithResults(db, channelCryptoKeysSql, channel))
+				for _, row := range sqlUtil.ExecuteQueryWithResults(db, channelClientKeysSql, channel) {
+					if _, ok := keysMap[fmt.Sprintf("%v", row[0].Value)]; !ok {
+						log.Warn().Msgf("SSL client key %v of channel %s is not exported, request it with --cryptoKeys", row[1].Value, channel)
+					}
+				}
+			}
+		}
+		if len(options.AutoinstallProfiles) > 0 {
+			addKeys(loadAutoinstallProfileCryptoKeys(db, options))
+		}
+	}
+	return keys
+}
+
+func processCryptoKeys(db *sql.DB, writer *bufio.Writer, options DumperOptions) {
+
+	keys := loadCryptoKeysToProcess(db, options)
+	log.Info().Msg(fmt.Sprintf("%d crypto keys to process", len(keys)))
+	if len(keys) == 0 {
+		return
+	}
+	schemaMetadata := readTablesSchema(db, CryptoKeyTableNames(), options)
+	log.Debug().Msg("crypto key schema metadata loaded")
+
+	keyDescriptions, err := os.Create(options.GetOutputFolderAbsPath() + "/exportedCryptoKeys.txt")
+	if err != nil {
+		log.Panic().Err(err).Msg("error creating exportedCryptoKeys file")
+	}
+	defer keyDescriptions.Close()
+	bufferWriterKeys := bufio.NewWriter(keyDescriptions)
+	defer bufferWriterKeys.Flush()
+
+	writer.WriteString("-- Crypto keys\n")
+	count := 0
+	for _, key := range keys {
+		count++
+		log.Debug().Msg(fmt.Sprintf("Processing crypto key [%d/%d] %s", count, len(keys), key.description))
+		whereFilter := fmt.Sprintf("id = %s", key.id)
+		tableData := dumper.DataCrawler(db, schemaMetadata, schemaMetadata["rhncryptokey"], whereFilter, options.StartingDate)
+		dumper.PrintTableDataOrdered(db, writer, schemaMetadata, schemaMetadata["rhncryptokey"],
+			tableData, dumper.PrintSqlOptions{})
+		writer.Flush()
+		bufferWriterKeys.WriteString(fmt.Sprintf("%s\n", key.description))
+	}
+	log.Debug().Msg("crypto key export finished")
+}
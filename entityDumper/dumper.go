@@ -5,49 +5,169 @@
 package entityDumper
 
 import (
-	"bufio"
-	"compress/gzip"
+	"database/sql"
 	"os"
 	"path"
 
 	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/inventory"
+	"github.com/uyuni-project/inter-server-sync/payload"
 	"github.com/uyuni-project/inter-server-sync/schemareader"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
 	"github.com/uyuni-project/inter-server-sync/utils"
 )
 
 func DumpAllEntities(options DumperOptions) {
 	var outputFolderAbs = options.GetOutputFolderAbsPath()
-	validateExportFolder(outputFolderAbs)
-
-	outFile := path.Join(outputFolderAbs, "/sql_statements.sql.gz")
-	file, err := os.OpenFile(outFile, os.O_WRONLY|os.O_CREATE, 0600)
-	if err != nil {
-		log.Panic().Err(err).Msg("error creating sql file")
+	if options.Resume {
+		options.checkpoint = resumeExportCheckpoint(outputFolderAbs, options)
+	} else {
+		validateExportFolder(outputFolderAbs)
+		options.checkpoint = newExportCheckpoint(outputFolderAbs, options)
 	}
-	defer closeAndSign(file, options.SignKey, options.PassFile)
-
-	gzipFile := gzip.NewWriter(file)
-	defer gzipFile.Close()
-
-	bufferWriter := bufio.NewWriterSize(gzipFile, 32768)
-	defer bufferWriter.Flush()
+	checkpoint := options.checkpoint
+	bufferWriter := checkpoint.writer
 
 	db := schemareader.GetDBconnection(options.ServerConfig)
 	defer db.Close()
-	bufferWriter.WriteString("BEGIN;\n")
+	options.exportedSchema = make(map[string]schemareader.Table)
+	if len(options.TargetInventory) > 0 {
+		targetInventory, err := inventory.Read(options.TargetInventory)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to read the inventory of the target server")
+		}
+		options.targetInventory = targetInventory
+	}
+	if options.FilesMode == FilesModeReference {
+		payloadList, err := payload.Create(outputFolderAbs)
+		if err != nil {
+			log.Panic().Err(err).Msg("error creating payload file lists")
+		}
+		options.payloadList = payloadList
+	}
+	if len(checkpoint.ExportStarted) == 0 {
+		// a resumed export keeps the time of the first run, changes since then are exported by the next incremental export
+		checkpoint.ExportStarted = databaseNow(db)
+		checkpoint.save()
+	}
+	if len(options.SinceExportState) > 0 {
+		previousState, err := readExportState(options.SinceExportState)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to export incrementally")
+		}
+		options.previousState = previousState
+	}
+	checkpoint.segment("begin", options, func() {
+		bufferWriter.WriteString("BEGIN;\n")
+	})
+	if len(options.ContentProjects) > 0 {
+		// channels built by the projects are needed on the target before the projects can be imported
+		options.ChannelLabels = append(options.ChannelLabels, loadContentProjectChannels(db, options)...)
+	}
+	// crypto keys are referenced by other entities, they need to be imported first
+	if len(options.CryptoKeys) > 0 || options.ReferencedCryptoKeys {
+		checkpoint.segment("cryptoKeys", options, func() {
+			processCryptoKeys(db, bufferWriter, options)
+		})
+	}
+	channels := make([]string, 0)
 	if len(options.ChannelLabels) > 0 || len(options.ChannelWithChildrenLabels) > 0 {
-		processAndInsertProducts(db, bufferWriter)
-		processAndInsertChannels(db, bufferWriter, options)
+		channels = loadChannelsToProcess(db, options)
+	}
+	var tombstones Tombstones
+	if options.previousState != nil && options.Tombstones {
+		tombstones = options.previousState.findTombstones(db)
+		log.Info().Msgf("%d entities were deleted since the previous export", tombstones.Count())
+	}
+	if options.previousState != nil {
+		if err := options.previousState.checkChannels(channels, tombstones[sqlUtil.EntityChannel]); err != nil {
+			log.Fatal().Err(err).Msg("Unable to export incrementally")
+		}
+		log.Info().Msgf("Exporting changes of %d channels since the export started at %s", len(channels), options.previousState.ExportStarted)
+	}
+	if len(channels) > 0 {
+		checkpoint.segment("products", options, func() {
+			processAndInsertProducts(db, bufferWriter, options)
+		})
+		// every channel is a segment of its own
+		processAndInsertChannels(db, bufferWriter, channels, options)
 	}
 	if len(options.ConfigLabels) > 0 {
 		processConfigs(db, bufferWriter, options)
 	}
 
+	if len(options.Distributions) > 0 {
+		checkpoint.segment("distributions", options, func() {
+			processDistributions(db, bufferWriter, options)
+		})
+	}
+
+	if len(options.AutoinstallProfiles) > 0 {
+		checkpoint.segment("autoinstallProfiles", options, func() {
+			processAutoinstallProfiles(db, bufferWriter, options)
+		})
+	}
+
+	if len(options.Errata) > 0 {
+		checkpoint.segment("errata", options, func() {
+			processErrata(db, bufferWriter, options)
+		})
+	}
+
+	if len(options.ContentProjects) > 0 {
+		checkpoint.segment("contentProjects", options, func() {
+			processContentProjects(db, bufferWriter, options)
+		})
+	}
+
+	if len(options.ActivationKeys) > 0 || options.ImageActivationKeys {
+		checkpoint.segment("activationKeys", options, func() {
+			processActivationKeys(db, bufferWriter, options)
+		})
+	}
+
 	if options.OSImages || options.Containers {
 		dumpImageData(db, bufferWriter, options)
 	}
 
-	bufferWriter.WriteString("COMMIT;\n")
+	checkpoint.segment("commit", options, func() {
+		bufferWriter.WriteString("COMMIT;\n")
+	})
+
+	if options.payloadList != nil {
+		if err := options.payloadList.Close(); err != nil {
+			log.Panic().Err(err).Msg("error writing payload file lists")
+		}
+	}
+	writeSchemaFingerprint(options)
+	if err := writeExportState(outputFolderAbs, checkpoint.ExportStarted, channels, exportStateEntities(db, checkpoint.Entities)); err != nil {
+		log.Panic().Err(err).Msg("error creating export state file")
+	}
+	if tombstones != nil {
+		if err := writeTombstones(outputFolderAbs, tombstones); err != nil {
+			log.Panic().Err(err).Msg("error creating tombstones file")
+		}
+	}
+	checkpoint.finish(options)
+}
+
+// readTablesSchema reads the schema of the tables and records it for the schema fingerprint of the export
+func readTablesSchema(db *sql.DB, tableNames []string, options DumperOptions) map[string]schemareader.Table {
+	schemaMetadata := schemareader.ReadTablesSchema(db, tableNames)
+	for name, table := range schemaMetadata {
+		options.exportedSchema[name] = table
+	}
+	return schemaMetadata
+}
+
+// writeSchemaFingerprint stores the fingerprint of all tables read during the export.
+// Import uses it to check the target schema is compatible with the exported data.
+func writeSchemaFingerprint(options DumperOptions) {
+	fingerprintFile := path.Join(options.GetOutputFolderAbsPath(), schemareader.SchemaFingerprintFileName)
+	// the checkpoint holds the tables read by previous runs of a resumed export as well
+	if err := schemareader.WriteSchemaFingerprint(options.checkpoint.Schema, fingerprintFile); err != nil {
+		log.Panic().Err(err).Msg("error creating schema fingerprint file")
+	}
 }
 
 func closeAndSign(f *os.File, cert string, passfile string) error {
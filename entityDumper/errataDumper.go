@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entityDumper
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/dumper"
+	"github.com/uyuni-project/inter-server-sync/dumper/packageDumper"
+	"github.com/uyuni-project/inter-server-sync/schemareader"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+// tablesToClean_errata represents Tables which needs to be cleaned in case on client side there is a record that doesn't exist anymore on master side
+var tablesToClean_errata = []string{
+	"rhnerratapackage",
+	"rhnerratafile",
+	"rhnerratafilepackage", "rhnerratafilepackagesource",
+	"rhnerratabuglist", "rhnerratacve",
+	"rhnerratakeyword",
+	"rhnpackageextratag"}
+
+// ErrataTableNames is the list of names of tables relevant for exporting single advisories.
+// Channel tables are left out on purpose, advisories are attached to existing channels on import.
+func ErrataTableNames() []string {
+	return []string{
+		"rhnerrata",
+		"rhnerratapackage",           // clean
+		"rhnerratafile",              // clean
+		"rhnerratafilepackage",       // clean
+		"rhnerratafilepackagesource", // clean
+		"rhnerratabuglist",           // clean
+		"rhncve",
+		"rhnerratacve",     // clean
+		"rhnerratakeyword", // clean
+		"rhnpackagenevra",
+		"rhnpackagename",
+		"rhnpackagegroup",
+		"rhnpackageevr",
+		"rhnchecksum",
+		"rhnpackage",
+		"rhnpackagekeyassociation",
+		"rhnpackagekey",
+		"rhnpackagecapability",
+		"rhnpackagebreaks",
+		"rhnpackageconflicts",
+		"rhnpackageenhances",
+		"rhnpackageextratag", // clean
+		"rhnpackageextratagkey",
+		"rhnpackagefile",
+		"rhnpackageobsoletes",
+		"rhnpackagepredepends",
+		"rhnpackageprovides",
+		"rhnpackagerecommends",
+		"rhnpackagerequires",
+		"rhnsourcerpm",
+		"rhnpackagesource",
+		"rhnpackagesuggests",
+		"rhnpackagesupplements",
+	}
+}
+
+type erratumToProcess struct {
+	id       string
+	advisory string
+	orgName  interface{}
+}
+
+var errataSql = "select e.id, e.advisory, wc.name from rhnerrata e " +
+	"left join web_customer wc on e.org_id = wc.id " +
+	"where e.advisory = $1"
+
+func loadErrataToProcess(db *sql.DB, options DumperOptions) []erratumToProcess {
+	log.Trace().Msg("Loading errata list")
+	orgFilter := make(map[string]bool)
+	for _, org := range options.Orgs {
+		orgFilter[fmt.Sprint(org)] = true
+	}
+
+	processed := make(map[string]bool)
+	errata := make([]erratumToProcess, 0)
+	for _, advisory := range options.Errata {
+		if _, ok := processed[advisory]; ok {
+			continue
+		}
+		processed[advisory] = true
+
+		sqlForErrata := errataSql
+		if len(orgFilter) > 0 {
+			orgs := make([]string, 0)
+			for org := range orgFilter {
+				orgs = append(orgs, org)
+			}
+			sqlForErrata = fmt.Sprintf("%s and (e.org_id is null or e.org_id in (%s))", errataSql, strings.Join(orgs, ", "))
+		}
+		dbErrata := sqlUtil.ExecuteQueryWithResults(db, sqlForErrata, advisory)
+		if len(dbErrata) == 0 {
+			log.Fatal().Msgf("Advisory not found: %s", advisory)
+		}
+		if len(dbErrata) > 1 {
+			log.Fatal().Msgf("Advisory %s exists in %d organizations. Please use `--orgLimit` to select the organization", advisory, len(dbErrata))
+		}
+		errata = append(errata, erratumToProcess{
+			id:       fmt.Sprintf("%v", dbErrata[0][0].Value),
+			advisory: advisory,
+			orgName:  dbErrata[0][2].Value,
+		})
+	}
+	return errata
+}
+
+func processErrata(db *sql.DB, writer *bufio.Writer, options DumperOptions) {
+
+	errata := loadErrataToProcess(db, options)
+	log.Info().Msg(fmt.Sprintf("%d errata to process", len(errata)))
+	if len(errata) == 0 {
+		return
+	}
+
+	errataTables := ErrataTableNames()
+	if !options.NoChangelogs {
+		errataTables = append(errataTables,
+			"rhnpackagechangelogdata",
+			"rhnpackagechangelogrec")
+	}
+	schemaMetadata := readTablesSchema(db, errataTables, options)
+	log.Debug().Msg("errata schema metadata loaded")
+
+	errataFile, err := os.Create(options.GetOutputFolderAbsPath() + "/exportedErrata.txt")
+	if err != nil {
+		log.Panic().Err(err).Msg("error creating exportedErrata file")
+	}
+	defer errataFile.Close()
+	bufferWriterErrata := bufio.NewWriter(errataFile)
+	defer bufferWriterErrata.Flush()
+
+	writer.WriteString("-- Errata\n")
+	if len(options.IntoChannel) > 0 {
+		generateTargetChannelCheck(options.IntoChannel, writer)
+	}
+	count := 0
+	for _, erratum := range errata {
+		count++
+		log.Info().Msg(fmt.Sprintf("Processing advisory [%d/%d] %s", count, len(errata), erratum.advisory))
+		processErratum(db, writer, erratum, schemaMetadata, options)
+		writer.Flush()
+		bufferWriterErrata.WriteString(fmt.Sprintf("%s\n", erratum.advisory))
+	}
+	if len(options.IntoChannel) > 0 {
+		generateCacheCalculation(options.IntoChannel, writer)
+	}
+}
+
+func processErratum(db *sql.DB, writer *bufio.Writer, erratum erratumToProcess,
+	schemaMetadata map[string]schemareader.Table, options DumperOptions) {
+	whereFilter := fmt.Sprintf("id = %s", erratum.id)
+	tableData := dumper.DataCrawler(db, schemaMetadata, schemaMetadata["rhnerrata"], whereFilter, options.StartingDate)
+	log.Debug().Msg("finished table data crawler")
+
+	cleanWhereClause := fmt.Sprintf(`WHERE rhnerrata.advisory = '%s' AND %s`, erratum.advisory, orgNameCondition("rhnerrata", erratum.orgName))
+	printOptions := dumper.PrintSqlOptions{
+		TablesToClean:            tablesToClean_errata,
+		CleanWhereClause:         cleanWhereClause,
+		OnlyIfParentExistsTables: onlyIfParentExistsTables,
+	}
+
+	dumper.PrintTableDataOrdered(db, writer, schemaMetadata, schemaMetadata["rhnerrata"],
+		tableData, printOptions)
+	log.Debug().Msg("finished print table order")
+
+	if len(options.IntoChannel) > 0 {
+		generateErratumChannelLink(erratum, options.IntoChannel, writer)
+	}
+
+	if !options.MetadataOnly {
+		log.Debug().Msg("dumping all package files")
+		packageDumper.DumpPackageFiles(db, schemaMetadata, tableData, options.GetOutputFolderAbsPath(), options.packageFileOptions())
+	}
+	log.Debug().Msg("advisory export finished")
+}
+
+// generateTargetChannelCheck aborts the import when the channel the advisories should be attached to does not exist
+func generateTargetChannelCheck(channelLabel string, writer *bufio.Writer) {
+	quotedLabel := pq.QuoteLiteral(channelLabel)
+	checkChannel := fmt.Sprintf(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM rhnchannel WHERE label = %s) THEN
+				RAISE EXCEPTION 'Target channel %% does not exist', %s;
+			END IF;
+		END $$;
+	`, quotedLabel, quotedLabel)
+	writer.WriteString(checkChannel + "\n")
+}
+
+// generateErratumChannelLink attaches the advisory and its packages to the target channel.
+// Only packages with an architecture compatible with the channel are added, the rest of the channel is not modified.
+func generateErratumChannelLink(erratum erratumToProcess, channelLabel string, writer *bufio.Writer) {
+	erratumCondition := fmt.Sprintf("rhnerrata.advisory = %s AND %s", pq.QuoteLiteral(erratum.advisory), orgNameCondition("rhnerrata", erratum.orgName))
+	quotedLabel := pq.QuoteLiteral(channelLabel)
+
+	channelErrata := fmt.Sprintf("INSERT INTO rhnchannelerrata (channel_id, errata_id) "+
+		"SELECT rhnchannel.id, rhnerrata.id FROM rhnchannel, rhnerrata "+
+		"WHERE rhnchannel.label = %s AND %s ON CONFLICT DO NOTHING;", quotedLabel, erratumCondition)
+	writer.WriteString(channelErrata + "\n")
+
+	channelPackages := fmt.Sprintf("INSERT INTO rhnchannelpackage (channel_id, package_id) "+
+		"SELECT rhnchannel.id, rhnpackage.id FROM rhnchannel, rhnerrata "+
+		"INNER JOIN rhnerratapackage on rhnerratapackage.errata_id = rhnerrata.id "+
+		"INNER JOIN rhnpackage on rhnpackage.id = rhnerratapackage.package_id "+
+		"WHERE rhnchannel.label = %s AND %s "+
+		"AND rhnpackage.package_arch_id IN (SELECT package_arch_id FROM rhnchannelpackagearchcompat WHERE channel_arch_id = rhnchannel.channel_arch_id) "+
+		"ON CONFLICT DO NOTHING;", quotedLabel, erratumCondition)
+	writer.WriteString(channelPackages + "\n")
+}
@@ -0,0 +1,27 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entityDumper
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTargetChannelCheck(t *testing.T) {
+	var output strings.Builder
+	writer := bufio.NewWriter(&output)
+	generateTargetChannelCheck("it's-100%", writer)
+	writer.Flush()
+
+	for _, expected := range []string{
+		"SELECT 1 FROM rhnchannel WHERE label = 'it''s-100%'",
+		"RAISE EXCEPTION 'Target channel % does not exist', 'it''s-100%';",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%s not found in %s", expected, output.String())
+		}
+	}
+}
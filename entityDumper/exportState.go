@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entityDumper
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ExportStateFileName records what a successful export contains, an incremental export continues from it
+const ExportStateFileName = "export_state.json"
+
+// exportState holds the database time every channel was exported at and the labels of all exported entities by kind.
+// Timestamps are taken from the database, so clock differences between the hosts do not matter.
+type exportState struct {
+	ExportStarted string              `json:"exportStarted"`
+	Channels      map[string]string   `json:"channels"`
+	Entities      map[string][]string `json:"entities,omitempty"`
+}
+
+// readExportState parses the content of the state file of the previous export
+func readExportState(data []byte) (*exportState, error) {
+	state := &exportState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid export state: %w", err)
+	}
+	return state, nil
+}
+
+// checkChannels fails when the channels to export are not the ones of the previous export.
+// Channels deleted since the previous export are not expected to be exported anymore.
+func (s *exportState) checkChannels(channels []string, deleted []string) error {
+	added := make([]string, 0)
+	exported := make(map[string]bool)
+	for _, channel := range channels {
+		exported[channel] = true
+		if _, ok := s.Channels[channel]; !ok {
+			added = append(added, channel)
+		}
+	}
+	for _, channel := range deleted {
+		exported[channel] = true
+	}
+	removed := make([]string, 0)
+	for channel := range s.Channels {
+		if !exported[channel] {
+			removed = append(removed, channel)
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return fmt.Errorf("the previous export was produced for a different channel set, not exported before: [%s], not exported now: [%s]",
+		strings.Join(added, ", "), strings.Join(removed, ", "))
+}
+
+// channelStartingDate returns the date the packages and errata of the channel are exported from
+func (opt *DumperOptions) channelStartingDate(channelLabel string) string {
+	if opt.previousState != nil {
+		return opt.previousState.Channels[channelLabel]
+	}
+	return opt.StartingDate
+}
+
+// databaseNow returns the current database time in the format used for starting dates
+func databaseNow(db *sql.DB) string {
+	var now string
+	if err := db.QueryRow("SELECT to_char(now(), 'YYYY-MM-DD HH24:MI:SS.US')").Scan(&now); err != nil {
+		log.Panic().Err(err).Msg("error reading the database time")
+	}
+	return now
+}
+
+// writeExportState records the channels and entities of the finished export
+func writeExportState(outputFolderAbs string, exportStarted string, channels []string, entities map[string][]string) error {
+	state := exportState{ExportStarted: exportStarted, Channels: make(map[string]string, len(channels)), Entities: entities}
+	for _, channel := range channels {
+		state.Channels[channel] = exportStarted
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(outputFolderAbs, ExportStateFileName), data, 0644)
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entityDumper
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExportState(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("SELECT to_char").WillReturnRows(sqlmock.NewRows([]string{"now"}).AddRow("2026-10-18 10:00:00.000001"))
+	exportStarted := databaseNow(db)
+
+	dir := t.TempDir()
+	if err := writeExportState(dir, exportStarted, []string{"base", "child"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path.Join(dir, ExportStateFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := readExportState(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := DumperOptions{StartingDate: "2020-01-01", previousState: state}
+	if date := options.channelStartingDate("child"); date != "2026-10-18 10:00:00.000001" {
+		t.Errorf("unexpected starting date %s", date)
+	}
+	if _, err := readExportState([]byte("{")); err == nil {
+		t.Error("invalid export state accepted")
+	}
+
+	if err := state.checkChannels([]string{"child", "base"}, nil); err != nil {
+		t.Errorf("unexpected error for the same channels: %s", err)
+	}
+	if err := state.checkChannels([]string{"base"}, []string{"child"}); err != nil {
+		t.Errorf("unexpected error for a deleted channel: %s", err)
+	}
+	err = state.checkChannels([]string{"base", "other"}, nil)
+	expected := "the previous export was produced for a different channel set, not exported before: [other], not exported now: [child]"
+	if err == nil || err.Error() != expected {
+		t.Errorf("unexpected error for different channels: %v", err)
+	}
+}
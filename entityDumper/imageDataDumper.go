@@ -23,7 +23,8 @@ var tablesToClean_images = []string{
 	"suseimageinfochannel",
 }
 
-// Activation keys are not exported - they are managed by uyuni formulas and/or XMLRPC/salt calls
+// Activation keys are not exported with images - they are exported on request by the activation key dumper,
+// either explicitly or as referenced by the exported image profiles.
 // If correct activation key is not present, OS images, particularly saltboot images, may not finish bootstrap correctly
 var imagesTableNames = []string{
 	// stores
@@ -142,7 +143,8 @@ func dumpOSImageTables(db *sql.DB, writer *bufio.Writer, schemaMetadata map[stri
 
 	// Images
 	needExtraExport := false
-	sqlForExistingImages := "SELECT id FROM suseimageinfo WHERE image_type = 'kiwi'"
+	sqlForExistingImages := "SELECT id, name, COALESCE(version, ''), COALESCE(curr_revision_num::text, ''), " +
+		"(SELECT wc.name FROM web_customer wc WHERE wc.id = suseimageinfo.org_id) FROM suseimageinfo WHERE image_type = 'kiwi'"
 	if isColumnInTable(schemaMetadata, "suseimageinfo", "built") {
 		// For 4.3 and newer export only succesfuly built images
 		sqlForExistingImages = fmt.Sprintf("%s AND built = 'Y'", sqlForExistingImages)
@@ -163,6 +165,7 @@ func dumpOSImageTables(db *sql.DB, writer *bufio.Writer, schemaMetadata map[stri
 		for _, image := range images {
 			log.Trace().Msgf("Exporting image id %s", image[0].Value)
 			whereClause := fmt.Sprintf("id = '%s'", image[0].Value)
+			writer.WriteString(sqlUtil.EntityBeginMarker(sqlUtil.EntityImage, imageEntityLabel(image)))
 			tableImageData := dumper.DataCrawler(db, schemaMetadata, schemaMetadata["suseimageinfo"], whereClause, options.StartingDate)
 			dumper.PrintTableDataOrdered(db, writer, schemaMetadata, schemaMetadata["suseimageinfo"], tableImageData, dumperOptions)
 			// Check if pillars are already in database
@@ -186,7 +189,7 @@ func dumpOSImageTables(db *sql.DB, writer *bufio.Writer, schemaMetadata map[stri
 					org := fmt.Sprintf("%s", imageFile[1].Value)
 					source := osImageDumper.GetImagePathForImage(file, org)
 					target := osImageDumper.GetImagePathForImage(file, org, outputFolderImagesAbs)
-					osImageDumper.DumpOsImage(target, source)
+					osImageDumper.DumpOsImage(target, source, options.LinkMode, options.payloadList)
 				}
 				// we marked this as exported for image files, now we need to unexport for the rest of the images
 				markAsUnexported(schemaMetadata, []string{"suseimageinfo"})
@@ -194,6 +197,7 @@ func dumpOSImageTables(db *sql.DB, writer *bufio.Writer, schemaMetadata map[stri
 				// pillars and thus image files are not in database, need extra export step
 				needExtraExport = true
 			}
+			options.checkpoint.endEntity(writer, sqlUtil.EntityImage, imageEntityLabel(image))
 		}
 	}
 
@@ -228,7 +232,8 @@ func dumpContainerImageTables(db *sql.DB, writer *bufio.Writer, schemaMetadata m
 	}
 
 	// Images
-	sqlForExistingImages := "SELECT id FROM suseimageinfo WHERE image_type = 'dockerfile'"
+	sqlForExistingImages := "SELECT id, name, COALESCE(version, ''), COALESCE(curr_revision_num::text, ''), " +
+		"(SELECT wc.name FROM web_customer wc WHERE wc.id = suseimageinfo.org_id) FROM suseimageinfo WHERE image_type = 'dockerfile'"
 	if isColumnInTable(schemaMetadata, "suseimageinfo", "built") {
 		// For 4.3 and newer export only succesfuly built images
 		sqlForExistingImages = fmt.Sprintf("%s AND built = 'Y'", sqlForExistingImages)
@@ -246,14 +251,67 @@ func dumpContainerImageTables(db *sql.DB, writer *bufio.Writer, schemaMetadata m
 		for _, image := range images {
 			log.Trace().Msgf("Exporting image id %s", image[0].Value)
 			whereClause := fmt.Sprintf("id = '%s'", image[0].Value)
+			writer.WriteString(sqlUtil.EntityBeginMarker(sqlUtil.EntityImage, imageEntityLabel(image)))
 			tableImageData := dumper.DataCrawler(db, schemaMetadata, schemaMetadata["suseimageinfo"], whereClause, options.StartingDate)
 			dumper.PrintTableDataOrdered(db, writer, schemaMetadata, schemaMetadata["suseimageinfo"], tableImageData, dumper.PrintSqlOptions{})
+			options.checkpoint.endEntity(writer, sqlUtil.EntityImage, imageEntityLabel(image))
 		}
 	}
 
 	log.Info().Msg("Dockerfile image export done")
 }
 
+// ImageEntity identifies an image in the entity markers and the tombstones of an export
+type ImageEntity struct {
+	Name     string
+	Version  string
+	Revision string
+	// Org is the name of the organization of the image
+	Org string
+}
+
+// Label returns the entity label of the image, name:version:revision@org
+func (i ImageEntity) Label() string {
+	return fmt.Sprintf("%s:%s:%s@%s", i.Name, i.Version, i.Revision, i.Org)
+}
+
+// ParseImageEntityLabel returns the image of the entity label. Labels of older exports, name:version only, are rejected.
+func ParseImageEntityLabel(label string) (ImageEntity, error) {
+	image := ImageEntity{}
+	at := strings.Index(label, "@")
+	if at < 0 {
+		return image, fmt.Errorf("image label %s has no organization", label)
+	}
+	image.Org = label[at+1:]
+	nameVersion, revision, ok := cutLast(label[:at], ":")
+	if !ok {
+		return image, fmt.Errorf("image label %s has no revision", label)
+	}
+	image.Revision = revision
+	if image.Name, image.Version, ok = cutLast(nameVersion, ":"); !ok {
+		return image, fmt.Errorf("image label %s has no version", label)
+	}
+	return image, nil
+}
+
+func cutLast(s string, separator string) (string, string, bool) {
+	index := strings.LastIndex(s, separator)
+	if index < 0 {
+		return s, "", false
+	}
+	return s[:index], s[index+len(separator):], true
+}
+
+// imageEntityLabel identifies the image of the row id, name, version, revision, organization in the entity markers
+func imageEntityLabel(image []sqlUtil.RowDataStructure) string {
+	return ImageEntity{
+		Name:     fmt.Sprintf("%v", image[1].Value),
+		Version:  fmt.Sprintf("%v", image[2].Value),
+		Revision: fmt.Sprintf("%v", image[3].Value),
+		Org:      fmt.Sprintf("%v", image[4].Value),
+	}.Label()
+}
+
 // Main entry point
 func dumpImageData(db *sql.DB, writer *bufio.Writer, options DumperOptions) {
 	log.Debug().Msg("Starting image metadata dump")
@@ -261,21 +319,30 @@ func dumpImageData(db *sql.DB, writer *bufio.Writer, options DumperOptions) {
 
 	// export DB data about images
 	log.Trace().Msg("Loading table schema")
-	schemaMetadata := schemareader.ReadTablesSchema(db, imagesTableNames)
+	schemaMetadata := readTablesSchema(db, imagesTableNames, options)
 
 	if options.OSImages {
 		var outputFolderImagesAbs = filepath.Join(outputFolderAbs, "images")
-		ValidateExportFolder(outputFolderImagesAbs)
-		dumpImageStores(db, writer, schemaMetadata, options, "os_image")
-		if dumpOSImageTables(db, writer, schemaMetadata, options, outputFolderImagesAbs) && !options.MetadataOnly {
-			// Pillars are transfered as part of the sql export
-			osImageDumper.DumpOsImages(outputFolderImagesAbs, options.Orgs)
+		if options.Resume {
+			// images of the interrupted export are overwritten
+			ValidateExistingFolder(outputFolderImagesAbs)
+		} else {
+			ValidateExportFolder(outputFolderImagesAbs)
 		}
+		options.checkpoint.segment("images:os", options, func() {
+			dumpImageStores(db, writer, schemaMetadata, options, "os_image")
+			if dumpOSImageTables(db, writer, schemaMetadata, options, outputFolderImagesAbs) && !options.MetadataOnly {
+				// Pillars are transfered as part of the sql export
+				osImageDumper.DumpOsImages(outputFolderImagesAbs, options.Orgs, options.LinkMode, options.payloadList)
+			}
+		})
 		// This is needed for containers to be able to export their respective tables
 		markAsUnexported(schemaMetadata, []string{"suseimagestore", "suseimageprofile"})
 	}
 	if options.Containers {
-		dumpImageStores(db, writer, schemaMetadata, options, "registry")
-		dumpContainerImageTables(db, writer, schemaMetadata, options)
+		options.checkpoint.segment("images:containers", options, func() {
+			dumpImageStores(db, writer, schemaMetadata, options, "registry")
+			dumpContainerImageTables(db, writer, schemaMetadata, options)
+		})
 	}
 }
@@ -0,0 +1,254 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entityDumper
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/cobbler"
+	"github.com/uyuni-project/inter-server-sync/dumper"
+	"github.com/uyuni-project/inter-server-sync/schemareader"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+// tablesToClean_autoinstallProfiles represents Tables which needs to be cleaned in case on client side there is a record that doesn't exist anymore on master side
+var tablesToClean_autoinstallProfiles = []string{
+	"rhnkickstartcommand",
+	"rhnksscript",
+	"rhnkickstartpackage",
+	"rhnkickstartchildchannel",
+	"rhnkickstartdefaultregtoken",
+	"rhncryptokeykickstart",
+	"rhnkickstartipranges",
+}
+
+// onlyIfParentExistsTables_autoinstallProfiles are profile links to channels, activation keys and crypto keys
+// which are exported separately and may be missing on the target server
+var onlyIfParentExistsTables_autoinstallProfiles = []string{
+	"rhnkickstartchildchannel",
+	"rhnkickstartdefaultregtoken",
+	"rhncryptokeykickstart",
+}
+
+// DistributionTableNames is the list of names of tables relevant for exporting autoinstallation distributions
+func DistributionTableNames() []string {
+	return []string{
+		"rhnkickstartabletree",
+	}
+}
+
+// AutoinstallProfileTableNames is the list of names of tables relevant for exporting autoinstallation profiles
+func AutoinstallProfileTableNames() []string {
+	return []string{
+		"rhnksdata",
+		"rhnkickstartdefaults",
+		"rhnkickstartabletree",
+		"rhnkickstartcommand",         // clean
+		"rhnksscript",                 // clean
+		"rhnkickstartpackage",         // clean
+		"rhnpackagename",              // packages of the profile
+		"rhnkickstartchildchannel",    // clean // add only if there are corresponding rows in rhnchannel
+		"rhnkickstartdefaultregtoken", // clean // add only if there are corresponding rows in rhnregtoken
+		"rhncryptokeykickstart",       // clean // add only if there are corresponding rows in rhncryptokey
+		"rhnkickstartipranges",        // clean
+	}
+}
+
+type kickstartEntityToProcess struct {
+	id      string
+	label   string
+	orgName interface{}
+}
+
+var distributionSql = "select t.id, t.label, wc.name from rhnkickstartabletree t " +
+	"left join web_customer wc on t.org_id = wc.id " +
+	"where t.label = $1"
+
+var autoinstallProfileSql = "select ks.id, ks.label, wc.name from rhnksdata ks " +
+	"left join web_customer wc on ks.org_id = wc.id " +
+	"where ks.label = $1"
+
+var autoinstallProfileFileSql = "select ks.ks_type, ks.org_id::text from rhnksdata ks where ks.id = $1"
+
+// keys used by the autoinstallation profile
+var autoinstallProfileCryptoKeysSql = "select distinct k.id, k.description from rhncryptokey k " +
+	"join rhncryptokeykickstart ck on ck.crypto_key_id = k.id " +
+	"where ck.ksdata_id = $1"
+
+func loadKickstartEntitiesToProcess(db *sql.DB, options DumperOptions, labels []string, entitySql string,
+	orgColumn string, entityName string) []kickstartEntityToProcess {
+	sqlForEntities := entitySql
+	if len(options.Orgs) > 0 {
+		orgs := make([]string, 0)
+		for _, org := range options.Orgs {
+			orgs = append(orgs, fmt.Sprint(org))
+		}
+		sqlForEntities = fmt.Sprintf("%s and (%s is null or %s in (%s))", entitySql, orgColumn, orgColumn, strings.Join(orgs, ", "))
+	}
+
+	processed := make(map[string]bool)
+	entities := make([]kickstartEntityToProcess, 0)
+	for _, label := range labels {
+		if _, ok := processed[label]; ok {
+			continue
+		}
+		processed[label] = true
+		dbEntities := sqlUtil.ExecuteQueryWithResults(db, sqlForEntities, label)
+		if len(dbEntities) == 0 {
+			log.Fatal().Msgf("%s not found: %s", entityName, label)
+		}
+		for _, row := range dbEntities {
+			entities = append(entities, kickstartEntityToProcess{
+				id:      fmt.Sprintf("%v", row[0].Value),
+				label:   label,
+				orgName: row[2].Value,
+			})
+		}
+	}
+	log.Debug().Msgf("%d %s entries to export", len(entities), entityName)
+	return entities
+}
+
+func loadDistributionsToProcess(db *sql.DB, options DumperOptions) []kickstartEntityToProcess {
+	return loadKickstartEntitiesToProcess(db, options, options.Distributions, distributionSql, "t.org_id", "Distribution")
+}
+
+func loadAutoinstallProfilesToProcess(db *sql.DB, options DumperOptions) []kickstartEntityToProcess {
+	return loadKickstartEntitiesToProcess(db, options, options.AutoinstallProfiles, autoinstallProfileSql, "ks.org_id", "Autoinstallation profile")
+}
+
+// loadAutoinstallProfileCryptoKeys returns the crypto keys used by the exported autoinstallation profiles
+func loadAutoinstallProfileCryptoKeys(db *sql.DB, options DumperOptions) [][]sqlUtil.RowDataStructure {
+	keys := make([][]sqlUtil.RowDataStructure, 0)
+	for _, profile := range loadAutoinstallProfilesToProcess(db, options) {
+		keys = append(keys, sqlUtil.ExecuteQueryWithResults(db, autoinstallProfileCryptoKeysSql, profile.id)...)
+	}
+	return keys
+}
+
+func processDistributions(db *sql.DB, writer *bufio.Writer, options DumperOptions) {
+
+	distributions := loadDistributionsToProcess(db, options)
+	log.Info().Msg(fmt.Sprintf("%d distributions to process", len(distributions)))
+	if len(distributions) == 0 {
+		return
+	}
+	schemaMetadata := readTablesSchema(db, DistributionTableNames(), options)
+	log.Debug().Msg("distribution schema metadata loaded")
+
+	distributionLabels, err := os.Create(options.GetOutputFolderAbsPath() + "/exportedDistributions.txt")
+	if err != nil {
+		log.Panic().Err(err).Msg("error creating exportedDistributions file")
+	}
+	defer distributionLabels.Close()
+	bufferWriterDistributions := bufio.NewWriter(distributionLabels)
+	defer bufferWriterDistributions.Flush()
+
+	writer.WriteString("-- Autoinstallation distributions\n")
+	count := 0
+	for _, distribution := range distributions {
+		count++
+		log.Info().Msg(fmt.Sprintf("Processing distribution [%d/%d] %s", count, len(distributions), distribution.label))
+		whereFilter := fmt.Sprintf("id = %s", distribution.id)
+		tableData := dumper.DataCrawler(db, schemaMetadata, schemaMetadata["rhnkickstartabletree"], whereFilter, options.StartingDate)
+		dumper.PrintTableDataOrdered(db, writer, schemaMetadata, schemaMetadata["rhnkickstartabletree"],
+			tableData, dumper.PrintSqlOptions{})
+		writer.Flush()
+		bufferWriterDistributions.WriteString(fmt.Sprintf("%s\n", distribution.label))
+	}
+	log.Debug().Msg("distribution export finished")
+}
+
+func processAutoinstallProfiles(db *sql.DB, writer *bufio.Writer, options DumperOptions) {
+
+	profiles := loadAutoinstallProfilesToProcess(db, options)
+	log.Info().Msg(fmt.Sprintf("%d autoinstallation profiles to process", len(profiles)))
+	if len(profiles) == 0 {
+		return
+	}
+	schemaMetadata := readTablesSchema(db, AutoinstallProfileTableNames(), options)
+	log.Debug().Msg("autoinstallation profile schema metadata loaded")
+
+	profileLabels, err := os.Create(options.GetOutputFolderAbsPath() + "/exportedAutoinstallProfiles.txt")
+	if err != nil {
+		log.Panic().Err(err).Msg("error creating exportedAutoinstallProfiles file")
+	}
+	defer profileLabels.Close()
+	bufferWriterProfiles := bufio.NewWriter(profileLabels)
+	defer bufferWriterProfiles.Flush()
+
+	writer.WriteString("-- Autoinstallation profiles\n")
+	count := 0
+	files := make([]cobbler.AutoinstallFile, 0)
+	for _, profile := range profiles {
+		count++
+		log.Info().Msg(fmt.Sprintf("Processing autoinstallation profile [%d/%d] %s", count, len(profiles), profile.label))
+		processAutoinstallProfile(db, writer, profile, schemaMetadata, options)
+		writer.Flush()
+		bufferWriterProfiles.WriteString(fmt.Sprintf("%s\n", profile.label))
+		files = append(files, exportAutoinstallFile(db, profile, options))
+	}
+
+	content, err := json.MarshalIndent(files, "", "  ")
+	if err != nil {
+		log.Panic().Err(err).Msg("error writing autoinstallation file list")
+	}
+	if err := os.WriteFile(path.Join(options.GetOutputFolderAbsPath(), cobbler.AutoinstallFileListName), content, 0644); err != nil {
+		log.Panic().Err(err).Msg("error writing autoinstallation file list")
+	}
+}
+
+// exportAutoinstallFile copies the autoinstallation file of the profile into the output folder.
+// The profile is listed without path when it has no autoinstallation file.
+func exportAutoinstallFile(db *sql.DB, profile kickstartEntityToProcess, options DumperOptions) cobbler.AutoinstallFile {
+	file := cobbler.AutoinstallFile{Label: profile.label}
+	if profile.orgName != nil {
+		file.Org = fmt.Sprintf("%v", profile.orgName)
+	}
+	rows := sqlUtil.ExecuteQueryWithResults(db, autoinstallProfileFileSql, profile.id)
+	if len(rows) == 0 {
+		return file
+	}
+	source := cobbler.AutoinstallFileName(profile.label, fmt.Sprintf("%v", rows[0][0].Value), fmt.Sprintf("%v", rows[0][1].Value))
+	content, err := os.ReadFile(path.Join(cobbler.AutoinstallDir, source))
+	if err != nil {
+		log.Warn().Err(err).Msgf("Autoinstallation file of profile %s is not exported", profile.label)
+		return file
+	}
+	file.Path = path.Join(cobbler.AutoinstallExportDir, path.Base(source))
+	target := path.Join(options.GetOutputFolderAbsPath(), file.Path)
+	if err := os.MkdirAll(path.Dir(target), 0755); err != nil {
+		log.Panic().Err(err).Msg("error creating autoinstallation file directory")
+	}
+	if err := os.WriteFile(target, content, 0644); err != nil {
+		log.Panic().Err(err).Msgf("error writing autoinstallation file of profile %s", profile.label)
+	}
+	return file
+}
+
+func processAutoinstallProfile(db *sql.DB, writer *bufio.Writer, profile kickstartEntityToProcess,
+	schemaMetadata map[string]schemareader.Table, options DumperOptions) {
+	whereFilter := fmt.Sprintf("id = %s", profile.id)
+	tableData := dumper.DataCrawler(db, schemaMetadata, schemaMetadata["rhnksdata"], whereFilter, options.StartingDate)
+	log.Debug().Msg("finished table data crawler")
+
+	cleanWhereClause := fmt.Sprintf(`WHERE rhnksdata.label = '%s' AND %s`, profile.label, orgNameCondition("rhnksdata", profile.orgName))
+	printOptions := dumper.PrintSqlOptions{
+		TablesToClean:            tablesToClean_autoinstallProfiles,
+		CleanWhereClause:         cleanWhereClause,
+		OnlyIfParentExistsTables: onlyIfParentExistsTables_autoinstallProfiles,
+	}
+
+	dumper.PrintTableDataOrdered(db, writer, schemaMetadata, schemaMetadata["rhnksdata"],
+		tableData, printOptions)
+	log.Debug().Msg("autoinstallation profile export finished")
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entityDumper
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+// TombstonesFileName lists the entities deleted on the source server since the previous export
+const TombstonesFileName = "tombstones.json"
+
+// Tombstones are the labels of deleted entities by entity kind, see sqlUtil.EntityChannel
+type Tombstones map[string][]string
+
+// Count returns the number of deleted entities
+func (t Tombstones) Count() int {
+	count := 0
+	for _, labels := range t {
+		count += len(labels)
+	}
+	return count
+}
+
+// entityExistsQueries check whether the entity with the label still exists in the source database
+var entityExistsQueries = map[string]string{
+	sqlUtil.EntityChannel:       "SELECT COUNT(*) FROM rhnchannel WHERE label = $1",
+	// same label as configChannelStateLabels
+	sqlUtil.EntityConfigChannel: "SELECT COUNT(*) FROM rhnconfigchannel WHERE label || '@' || " +
+		"(SELECT wc.name FROM web_customer wc WHERE wc.id = rhnconfigchannel.org_id) = $1",
+	// same label as ImageEntity.Label
+	sqlUtil.EntityImage: "SELECT COUNT(*) FROM suseimageinfo WHERE name || ':' || COALESCE(version, '') || ':' || " +
+		"COALESCE(curr_revision_num::text, '') || '@' || (SELECT wc.name FROM web_customer wc WHERE wc.id = suseimageinfo.org_id) = $1",
+}
+
+// findTombstones returns the entities of the previous export which do not exist in the database anymore
+func (s *exportState) findTombstones(db *sql.DB) Tombstones {
+	previous := make(map[string][]string, len(s.Entities)+1)
+	for kind, labels := range s.Entities {
+		previous[kind] = labels
+	}
+	// channels are always recorded with their starting date, states of older exports have no entities
+	previous[sqlUtil.EntityChannel] = make([]string, 0, len(s.Channels))
+	for channel := range s.Channels {
+		previous[sqlUtil.EntityChannel] = append(previous[sqlUtil.EntityChannel], channel)
+	}
+
+	tombstones := make(Tombstones)
+	for kind, labels := range previous {
+		query, ok := entityExistsQueries[kind]
+		if !ok {
+			continue
+		}
+		for _, label := range labels {
+			if kind == sqlUtil.EntityImage {
+				if _, err := ParseImageEntityLabel(label); err != nil {
+					log.Warn().Err(err).Msg("Image of the previous export cannot be identified, it is not checked for deletion")
+					continue
+				}
+			}
+			if kind == sqlUtil.EntityConfigChannel {
+				if _, _, err := ParseConfigChannelStateLabel(label); err != nil {
+					log.Warn().Err(err).Msg("Configuration channel of the previous export cannot be identified, it is not checked for deletion")
+					continue
+				}
+			}
+			var count int
+			if err := db.QueryRow(query, label).Scan(&count); err != nil {
+				log.Panic().Err(err).Msgf("error checking whether %s %s exists", kind, label)
+			}
+			if count == 0 {
+				tombstones[kind] = append(tombstones[kind], label)
+			}
+		}
+		sort.Strings(tombstones[kind])
+	}
+	return tombstones
+}
+
+// exportStateEntities returns the exported entities recorded in the export state. Configuration channels are exported
+// by label from every organization, they are recorded per organization to be deleted in the right one.
+func exportStateEntities(db *sql.DB, entities map[string][]string) map[string][]string {
+	stateEntities := make(map[string][]string, len(entities))
+	for kind, labels := range entities {
+		stateEntities[kind] = labels
+	}
+	if labels := entities[sqlUtil.EntityConfigChannel]; len(labels) > 0 {
+		stateEntities[sqlUtil.EntityConfigChannel] = configChannelStateLabels(db, labels)
+	}
+	return stateEntities
+}
+
+// configChannelStateLabels returns the configuration channels with the labels as label@org
+func configChannelStateLabels(db *sql.DB, labels []string) []string {
+	rows, err := db.Query("SELECT cc.label || '@' || wc.name FROM rhnconfigchannel cc "+
+		"JOIN web_customer wc ON wc.id = cc.org_id WHERE cc.label = ANY($1) ORDER BY 1", pq.Array(labels))
+	if err != nil {
+		log.Panic().Err(err).Msg("error reading the organizations of the exported configuration channels")
+	}
+	defer rows.Close()
+	stateLabels := make([]string, 0, len(labels))
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			log.Panic().Err(err).Msg("error reading the organizations of the exported configuration channels")
+		}
+		stateLabels = append(stateLabels, label)
+	}
+	if err := rows.Err(); err != nil {
+		log.Panic().Err(err).Msg("error reading the organizations of the exported configuration channels")
+	}
+	return stateLabels
+}
+
+// ParseConfigChannelStateLabel returns the label and the organization name of a configuration channel of the export
+// state and the tombstones, label@org. Labels of older exports, without organization, are rejected.
+func ParseConfigChannelStateLabel(stateLabel string) (string, string, error) {
+	label, org, ok := strings.Cut(stateLabel, "@")
+	if !ok || len(label) == 0 || len(org) == 0 {
+		return "", "", fmt.Errorf("configuration channel %s has no organization", stateLabel)
+	}
+	return label, org, nil
+}
+
+func writeTombstones(outputFolderAbs string, tombstones Tombstones) error {
+	data, err := json.MarshalIndent(tombstones, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(outputFolderAbs, TombstonesFileName), data, 0644)
+}
+
+// ReadTombstones reads the deleted entities of the export directory, it returns nil when the export has none
+func ReadTombstones(exportDir string) (Tombstones, error) {
+	data, err := os.ReadFile(path.Join(exportDir, TombstonesFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	tombstones := make(Tombstones)
+	if err := json.Unmarshal(data, &tombstones); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", TombstonesFileName, err)
+	}
+	return tombstones, nil
+}
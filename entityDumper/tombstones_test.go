@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package entityDumper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+func TestFindTombstones(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(false)
+	exists := func(table string, label string, count int) {
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM " + table).WithArgs(label).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+	}
+	exists("rhnchannel", "base", 1)
+	exists("rhnchannel", "removed-child", 0)
+	exists("rhnconfigchannel", "removed-config@Org", 0)
+	exists("suseimageinfo", "image:1.0:1@Org", 1)
+	exists("suseimageinfo", "removed-image:2.0:1@Org", 0)
+	// configuration channels and images of older exports are not identified by organization
+
+	state := &exportState{
+		Channels: map[string]string{"base": "2026-10-18", "removed-child": "2026-10-18"},
+		Entities: map[string][]string{
+			sqlUtil.EntityChannel:       {"base", "removed-child"},
+			sqlUtil.EntityConfigChannel: {"removed-config@Org", "old-config"},
+			sqlUtil.EntityImage:         {"image:1.0:1@Org", "removed-image:2.0:1@Org", "old-image:1.0"},
+		},
+	}
+	tombstones := state.findTombstones(db)
+	expected := Tombstones{
+		sqlUtil.EntityChannel:       {"removed-child"},
+		sqlUtil.EntityConfigChannel: {"removed-config@Org"},
+		sqlUtil.EntityImage:         {"removed-image:2.0:1@Org"},
+	}
+	if !reflect.DeepEqual(tombstones, expected) {
+		t.Errorf("unexpected tombstones %v", tombstones)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	dir := t.TempDir()
+	if empty, err := ReadTombstones(dir); err != nil || empty != nil {
+		t.Errorf("unexpected tombstones of an export without them: %v, %v", empty, err)
+	}
+	if err := writeTombstones(dir, tombstones); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadTombstones(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, expected) || read.Count() != 3 {
+		t.Errorf("unexpected tombstones read %v", read)
+	}
+}
+
+func TestParseImageEntityLabel(t *testing.T) {
+	image := ImageEntity{Name: "suse/sles", Version: "15.6", Revision: "2", Org: "My Org @ Home"}
+	if parsed, err := ParseImageEntityLabel(image.Label()); err != nil || parsed != image {
+		t.Errorf("unexpected image %v of label %s: %v", parsed, image.Label(), err)
+	}
+	image = ImageEntity{Name: "image", Org: "Org"}
+	if parsed, err := ParseImageEntityLabel(image.Label()); err != nil || parsed != image {
+		t.Errorf("unexpected image %v of label %s: %v", parsed, image.Label(), err)
+	}
+	for _, label := range []string{"image:1.0", "image@Org", "image:1@Org"} {
+		if _, err := ParseImageEntityLabel(label); err == nil {
+			t.Errorf("label %s accepted", label)
+		}
+	}
+}
+
+func TestExportStateEntities(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// configuration channels with the same label in two organizations are recorded for both
+	mock.ExpectQuery("SELECT cc.label").
+		WillReturnRows(sqlmock.NewRows([]string{"label"}).AddRow("config@Org").AddRow("config@Other Org"))
+
+	entities := map[string][]string{
+		sqlUtil.EntityChannel:       {"base"},
+		sqlUtil.EntityConfigChannel: {"config"},
+	}
+	expected := map[string][]string{
+		sqlUtil.EntityChannel:       {"base"},
+		sqlUtil.EntityConfigChannel: {"config@Org", "config@Other Org"},
+	}
+	if stateEntities := exportStateEntities(db, entities); !reflect.DeepEqual(stateEntities, expected) {
+		t.Errorf("unexpected state entities %v", stateEntities)
+	}
+	if !reflect.DeepEqual(entities[sqlUtil.EntityConfigChannel], []string{"config"}) {
+		t.Error("exported entities modified")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	for _, label := range []string{"config", "@Org", "config@"} {
+		if _, _, err := ParseConfigChannelStateLabel(label); err == nil {
+			t.Errorf("label %s accepted", label)
+		}
+	}
+}
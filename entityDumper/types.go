@@ -5,14 +5,36 @@
 package entityDumper
 
 import (
+	"github.com/uyuni-project/inter-server-sync/dumper/packageDumper"
+	"github.com/uyuni-project/inter-server-sync/inventory"
+	"github.com/uyuni-project/inter-server-sync/payload"
+	"github.com/uyuni-project/inter-server-sync/schemareader"
 	"github.com/uyuni-project/inter-server-sync/utils"
 )
 
+// Files modes of the exported package and image files
+const (
+	// FilesModeCopy writes the files into the output folder, see DumperOptions.LinkMode
+	FilesModeCopy = "copy"
+	// FilesModeReference lists the files in the payload lists instead, see payload.List
+	FilesModeReference = "reference"
+)
+
 type DumperOptions struct {
 	ServerConfig              string
 	ChannelLabels             []string
 	ConfigLabels              []string
+	ActivationKeys            []string
+	ImageActivationKeys       bool
+	ContentProjects           []string
+	Errata                    []string
+	IntoChannel               string
+	CryptoKeys                []string
+	ReferencedCryptoKeys      bool
+	Distributions             []string
+	AutoinstallProfiles       []string
 	ChannelWithChildrenLabels []string
+	WithRepositories          bool
 	OutputFolder              string
 	outputFolderAbsPath       string
 	MetadataOnly              bool
@@ -23,6 +45,30 @@ type DumperOptions struct {
 	Orgs                      []uint
 	SignKey                   string
 	PassFile                  string
+	// SinceExportState is the content of the state file of the previous export, channels are exported incrementally from it
+	SinceExportState []byte
+	// Tombstones records the entities of the previous export deleted since then, they are deleted by the import
+	Tombstones bool
+	// CopyWorkers is the number of package files copied in parallel
+	CopyWorkers int
+	// LinkMode is how package and image files are written to the output folder, see dumper.ExportFile
+	LinkMode string
+	// FilesMode is either FilesModeCopy or FilesModeReference
+	FilesMode string
+	// TargetInventory is the package inventory of the target server, package files it has are not exported
+	TargetInventory string
+	// Resume continues the interrupted export in OutputFolder, see exportCheckpoint
+	Resume bool
+	// state of the previous export when exporting incrementally
+	previousState *exportState
+	// referenced package and image files in FilesModeReference
+	payloadList *payload.List
+	// package files of the target server read from TargetInventory
+	targetInventory inventory.Inventory
+	// progress of the export, SQL statements are written to its writer
+	checkpoint *exportCheckpoint
+	// schemas of all tables read during the export, see readTablesSchema
+	exportedSchema map[string]schemareader.Table
 }
 
 func (opt *DumperOptions) GetOutputFolderAbsPath() string {
@@ -32,6 +78,17 @@ func (opt *DumperOptions) GetOutputFolderAbsPath() string {
 	return opt.outputFolderAbsPath
 }
 
+// packageFileOptions returns how the package files are exported, files of an interrupted export are reused
+func (opt *DumperOptions) packageFileOptions() packageDumper.FileOptions {
+	return packageDumper.FileOptions{
+		ReuseVerified:   opt.Resume,
+		Workers:         opt.CopyWorkers,
+		LinkMode:        opt.LinkMode,
+		PayloadList:     opt.payloadList,
+		TargetInventory: opt.targetInventory,
+	}
+}
+
 type channelsProcess struct {
 	channelsMap map[string]bool
 	channels    []string
@@ -41,3 +98,24 @@ func (c *channelsProcess) addChannelLabel(label string) {
 	c.channelsMap[label] = true
 	c.channels = append(c.channels, label)
 }
+
+// labelSet keeps the labels of entities to export in the order they were added, each label once
+type labelSet struct {
+	added  map[string]bool
+	labels []string
+}
+
+func newLabelSet() *labelSet {
+	return &labelSet{added: make(map[string]bool), labels: make([]string, 0)}
+}
+
+func (s *labelSet) contains(label string) bool {
+	return s.added[label]
+}
+
+func (s *labelSet) add(label string) {
+	if !s.added[label] {
+		s.added[label] = true
+		s.labels = append(s.labels, label)
+	}
+}
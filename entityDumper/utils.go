@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
+	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
 	"github.com/uyuni-project/inter-server-sync/utils"
 )
@@ -36,3 +38,12 @@ func ValidateExistingFolder(outputFolderAbs string) {
 		}
 	}
 }
+
+// orgNameCondition identifies the organization of the table row by its name, the same way foreign keys are exported
+func orgNameCondition(tableName string, orgName interface{}) string {
+	if orgName == nil {
+		return fmt.Sprintf("%s.org_id IS NULL", tableName)
+	}
+	quotedName := strings.TrimSpace(pq.QuoteLiteral(fmt.Sprintf("%s", orgName)))
+	return fmt.Sprintf("%s.org_id = (SELECT id FROM web_customer WHERE name = %s LIMIT 1)", tableName, quotedName)
+}
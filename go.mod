@@ -1,13 +1,16 @@
 module github.com/uyuni-project/inter-server-sync
 
-go 1.20
+go 1.22
 
 require (
 	github.com/DATA-DOG/go-sqlmock v1.5.0
+	github.com/klauspost/compress v1.18.0
 	github.com/lib/pq v1.8.0
 	github.com/rs/zerolog v1.21.0
 	github.com/spf13/cobra v1.1.3
 	github.com/uyuni-project/xmlrpc-public-methods v0.0.0-20200805144514-2ca831c526d1
+	github.com/youmark/pkcs8 v0.0.0-20240726163527-a2c0da244d78
+	golang.org/x/crypto v0.33.0
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1
 )
 
@@ -43,14 +43,13 @@ Source0:        %{name}-%{version}.tar.gz
 Source1:        vendor.tar.gz
 BuildRequires:  golang-packaging
 %if 0%{?rhel}
-BuildRequires:  golang >= 1.18
+BuildRequires:  golang >= 1.22
 %else
-BuildRequires:  golang(API) >= 1.20
+BuildRequires:  golang(API) >= 1.22
 %endif
 
 Requires:       gzip
 Requires:       systemd
-Requires:       openssl
 
 %description
 Uyuni inter server sync tool
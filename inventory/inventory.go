@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"bufio"
+	"compress/gzip"
+	"database/sql"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// header is the first line of an inventory file
+const header = "# inter-server-sync package inventory v1"
+
+// Inventory maps the paths of the package files of a server to their "<type>:<hex value>" checksum
+type Inventory map[string]string
+
+func formatChecksum(checksumType string, checksum string) string {
+	return strings.ToLower(checksumType) + ":" + strings.ToLower(checksum)
+}
+
+// Create lists the packages of the database whose files exist in dataFolder
+func Create(db *sql.DB, dataFolder string) (Inventory, error) {
+	rows, err := db.Query("SELECT p.path, ct.label, c.checksum FROM rhnpackage p " +
+		"JOIN rhnchecksum c ON p.checksum_id = c.id " +
+		"JOIN rhnchecksumtype ct ON c.checksum_type_id = ct.id WHERE p.path IS NOT NULL")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	inventory := make(Inventory)
+	missing := 0
+	for rows.Next() {
+		var packagePath, checksumType, checksum string
+		if err := rows.Scan(&packagePath, &checksumType, &checksum); err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(path.Join(dataFolder, packagePath)); err != nil {
+			log.Trace().Err(err).Msgf("package file %s not listed", packagePath)
+			missing++
+			continue
+		}
+		inventory[packagePath] = formatChecksum(checksumType, checksum)
+	}
+	if missing > 0 {
+		log.Warn().Msgf("%d package files are missing in %s, they are not listed", missing, dataFolder)
+	}
+	return inventory, rows.Err()
+}
+
+// Has checks the inventory lists the package file with the same checksum
+func (i Inventory) Has(packagePath string, checksumType string, checksum string) bool {
+	listed, ok := i[packagePath]
+	return ok && listed == formatChecksum(checksumType, checksum)
+}
+
+// Write writes the inventory as a gzip compressed list of "<checksum>\t<path>" lines sorted by path
+func (i Inventory) Write(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gzipWriter := gzip.NewWriter(file)
+	writer := bufio.NewWriter(gzipWriter)
+	writer.WriteString(header + "\n")
+	paths := make([]string, 0, len(i))
+	for packagePath := range i {
+		paths = append(paths, packagePath)
+	}
+	sort.Strings(paths)
+	for _, packagePath := range paths {
+		writer.WriteString(i[packagePath] + "\t" + packagePath + "\n")
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Read reads the inventory written by Write
+func Read(fileName string) (Inventory, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid inventory %s: %w", fileName, err)
+	}
+	defer gzipReader.Close()
+	scanner := bufio.NewScanner(gzipReader)
+	if !scanner.Scan() || scanner.Text() != header {
+		return nil, fmt.Errorf("invalid inventory %s: unknown format", fileName)
+	}
+	inventory := make(Inventory)
+	for lineNumber := 2; scanner.Scan(); lineNumber++ {
+		checksum, packagePath, found := strings.Cut(scanner.Text(), "\t")
+		if !found {
+			return nil, fmt.Errorf("invalid inventory %s: invalid entry on line %d", fileName, lineNumber)
+		}
+		inventory[packagePath] = checksum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid inventory %s: %w", fileName, err)
+	}
+	return inventory, nil
+}
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestInventory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	dataFolder := t.TempDir()
+	if err := os.MkdirAll(path.Join(dataFolder, "packages/1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path.Join(dataFolder, "packages/1/a.rpm"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery("SELECT p.path, ct.label, c.checksum FROM rhnpackage").WillReturnRows(
+		sqlmock.NewRows([]string{"path", "label", "checksum"}).
+			AddRow("packages/1/a.rpm", "sha256", "ABC123").
+			AddRow("packages/1/missing.rpm", "sha256", "def456"))
+
+	created, err := Create(db, dataFolder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(created, Inventory{"packages/1/a.rpm": "sha256:abc123"}) {
+		t.Errorf("unexpected inventory %v", created)
+	}
+
+	fileName := path.Join(t.TempDir(), "inventory.gz")
+	if err := created.Write(fileName); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !read.Has("packages/1/a.rpm", "SHA256", "abc123") {
+		t.Error("expected package file with the same checksum in the inventory")
+	}
+	if read.Has("packages/1/a.rpm", "sha256", "0000") || read.Has("packages/1/missing.rpm", "sha256", "def456") {
+		t.Error("unexpected package file in the inventory")
+	}
+	var empty Inventory
+	if empty.Has("packages/1/a.rpm", "sha256", "abc123") {
+		t.Error("unexpected package file in an empty inventory")
+	}
+
+	if err := os.WriteFile(fileName, []byte("not an inventory"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(fileName); err == nil {
+		t.Error("expected error for invalid inventory")
+	}
+}
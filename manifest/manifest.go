@@ -0,0 +1,219 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+	"github.com/uyuni-project/inter-server-sync/utils"
+)
+
+// FileName is the manifest of the export, signed like the SQL file
+const FileName = "manifest.json"
+
+// SignatureFileName is the signature of the manifest
+const SignatureFileName = FileName + ".sha512"
+
+// entityListings are the files listing exported entities not marked in the SQL file
+var entityListings = map[string]string{
+	"exportedActivationKeys.txt":      "activation-key",
+	"exportedAutoinstallProfiles.txt": "autoinstall-profile",
+	"exportedContentProjects.txt":     "content-project",
+	"exportedCryptoKeys.txt":          "crypto-key",
+	"exportedDistributions.txt":       "distribution",
+	"exportedErrata.txt":              "errata",
+}
+
+// File is an exported file, Path is relative to the export directory
+type File struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+// Manifest describes the content of an export
+type Manifest struct {
+	// Entities are the labels of the exported entities by kind
+	Entities map[string][]string `json:"entities"`
+	// Tables are the number of exported rows by table
+	Tables map[string]int `json:"tables"`
+	Files  []File         `json:"files"`
+}
+
+// Create builds the manifest of the export directory
+func Create(exportDir string) (*Manifest, error) {
+	manifest, err := ReadContent(exportDir)
+	if err != nil {
+		return nil, err
+	}
+	err = filepath.WalkDir(exportDir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relativePath, err := filepath.Rel(exportDir, filePath)
+		if err != nil {
+			return err
+		}
+		if isManifestFile(relativePath) {
+			return nil
+		}
+		file, err := describeFile(filePath)
+		if err != nil {
+			return err
+		}
+		file.Path = filepath.ToSlash(relativePath)
+		manifest.Files = append(manifest.Files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// ReadContent reads the exported entities and the rows per table of the export directory, files are not listed
+func ReadContent(exportDir string) (*Manifest, error) {
+	manifest := &Manifest{
+		Entities: make(map[string][]string),
+		Tables:   make(map[string]int),
+		Files:    make([]File, 0),
+	}
+	for listing, kind := range entityListings {
+		listingFile := path.Join(exportDir, listing)
+		if _, err := os.Stat(listingFile); err != nil {
+			continue
+		}
+		for _, label := range utils.ReadFileByLine(listingFile) {
+			if len(label) > 0 {
+				manifest.Entities[kind] = append(manifest.Entities[kind], label)
+			}
+		}
+	}
+	if err := manifest.countStatements(exportDir); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// countStatements reads the entity markers and the rows inserted per table from the SQL file
+func (m *Manifest) countStatements(exportDir string) error {
+	sqlFile, err := sqlUtil.FindSqlFile(exportDir)
+	if err != nil {
+		return err
+	}
+	reader, err := sqlUtil.OpenSqlFile(sqlFile)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	statements := sqlUtil.NewStatementReader(reader)
+	for {
+		statement, err := statements.Next()
+		for _, comment := range statements.Comments() {
+			if marker, ok := sqlUtil.ParseEntityMarker(comment); ok && marker.Begin {
+				m.Entities[marker.Kind] = append(m.Entities[marker.Kind], marker.Label)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading statement %d of the SQL file: %w", statements.Count()+1, err)
+		}
+		if sqlUtil.StatementType(statement) == "INSERT" {
+			m.Tables[sqlUtil.StatementTable(statement)]++
+		}
+	}
+}
+
+// Write stores the manifest in the export directory
+func (m *Manifest) Write(exportDir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(exportDir, FileName), data, 0644)
+}
+
+// Read loads the manifest of the export directory
+func Read(exportDir string) (*Manifest, error) {
+	data, err := os.ReadFile(path.Join(exportDir, FileName))
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// Verify checks every file of the export directory is listed in the manifest with the same size and checksum.
+// It returns the problems found, sorted by file.
+func (m *Manifest) Verify(exportDir string) ([]string, error) {
+	problems := make([]string, 0)
+	listed := make(map[string]bool, len(m.Files))
+	for _, expected := range m.Files {
+		listed[expected.Path] = true
+		actual, err := describeFile(filepath.Join(exportDir, filepath.FromSlash(expected.Path)))
+		switch {
+		case os.IsNotExist(err):
+			problems = append(problems, fmt.Sprintf("%s: missing", expected.Path))
+		case err != nil:
+			return nil, err
+		case actual.Size != expected.Size:
+			problems = append(problems, fmt.Sprintf("%s: size %d, expected %d", expected.Path, actual.Size, expected.Size))
+		case actual.Sha256 != expected.Sha256:
+			problems = append(problems, fmt.Sprintf("%s: checksum mismatch", expected.Path))
+		}
+	}
+	err := filepath.WalkDir(exportDir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relativePath, err := filepath.Rel(exportDir, filePath)
+		if err != nil {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+		if !isManifestFile(relativePath) && !listed[relativePath] {
+			problems = append(problems, fmt.Sprintf("%s: not listed in the manifest", relativePath))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
+
+func isManifestFile(relativePath string) bool {
+	return relativePath == FileName || relativePath == SignatureFileName
+}
+
+func describeFile(filePath string) (File, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return File{}, err
+	}
+	defer f.Close()
+	checksum := sha256.New()
+	size, err := io.Copy(checksum, f)
+	if err != nil {
+		return File{}, err
+	}
+	return File{Size: size, Sha256: hex.EncodeToString(checksum.Sum(nil))}, nil
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package manifest
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/uyuni-project/inter-server-sync/sqlUtil"
+)
+
+func writeExport(t *testing.T, dir string) {
+	sql := "BEGIN;\n" +
+		sqlUtil.EntityBeginMarker(sqlUtil.EntityChannel, "test-channel") +
+		"INSERT INTO rhnchannel (label) VALUES ('test-channel');\n" +
+		"INSERT INTO rhnchannelpackage (channel_id) VALUES (1);\n" +
+		"INSERT INTO rhnchannelpackage (channel_id) VALUES (2);\n" +
+		"DELETE FROM rhnchannelpackage WHERE channel_id = 3;\n" +
+		sqlUtil.EntityEndMarker(sqlUtil.EntityChannel, "test-channel") +
+		"COMMIT;\n"
+	files := map[string]string{
+		"sql_statements.sql":                 sql,
+		"exportedErrata.txt":                 "SUSE-2026-1\n",
+		"packages/1/abc/test/test-1.0.x.rpm": "package",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(path.Dir(path.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	writeExport(t, dir)
+
+	manifest, err := Create(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedEntities := map[string][]string{"channel": {"test-channel"}, "errata": {"SUSE-2026-1"}}
+	if !reflect.DeepEqual(manifest.Entities, expectedEntities) {
+		t.Errorf("unexpected entities: %v", manifest.Entities)
+	}
+	if !reflect.DeepEqual(manifest.Tables, map[string]int{"rhnchannel": 1, "rhnchannelpackage": 2}) {
+		t.Errorf("unexpected table rows: %v", manifest.Tables)
+	}
+	rpm := File{Path: "packages/1/abc/test/test-1.0.x.rpm", Size: 7,
+		Sha256: "bc4a71180870f7945155fbb02f4b0a2e3faa2a62d6d31b7039013055ed19869a"}
+	if len(manifest.Files) != 3 || manifest.Files[1] != rpm {
+		t.Errorf("unexpected files: %v", manifest.Files)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	writeExport(t, dir)
+	created, err := Create(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := created.Write(dir); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems, err := manifest.Verify(dir); err != nil || len(problems) > 0 {
+		t.Fatalf("unexpected verification result: %v %v", problems, err)
+	}
+
+	os.WriteFile(path.Join(dir, "packages/1/abc/test/test-1.0.x.rpm"), []byte("tampere"), 0644)
+	os.WriteFile(path.Join(dir, "packages/extra.rpm"), []byte("extra"), 0644)
+	os.Remove(path.Join(dir, "exportedErrata.txt"))
+	problems, err := manifest.Verify(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"exportedErrata.txt: missing",
+		"packages/1/abc/test/test-1.0.x.rpm: checksum mismatch",
+		"packages/extra.rpm: not listed in the manifest",
+	}
+	if !reflect.DeepEqual(problems, expected) {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+}
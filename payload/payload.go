@@ -0,0 +1,263 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package payload
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/uyuni-project/inter-server-sync/utils"
+)
+
+const (
+	// FileListName lists all referenced files with their size and checksum, one tab separated entry per line
+	FileListName = "payload_files.tsv"
+	// PackageListName lists the package files relative to the package directory of the source server,
+	// for `rsync --files-from=packages.files /var/spacewalk/ <payload directory>/`
+	PackageListName = "packages.files"
+	// ImageListName lists the image files relative to the image directory of the source server,
+	// for `rsync --files-from=images.files /srv/www/os-images/ <payload directory>/images/`
+	ImageListName = "images.files"
+)
+
+// ImagesDir is the directory of the image files in the payload directory
+const ImagesDir = "images"
+
+// imageChecksumType is the checksum computed for image files, package files use the checksum of the database
+const imageChecksumType = "sha256"
+
+var fileListHeader = strings.Join([]string{"source", "destination", "size", "checksum"}, "\t")
+
+// Entry is a referenced file. Destination is relative to the payload directory, Checksum is "<type>:<hex value>".
+type Entry struct {
+	Source      string
+	Destination string
+	Size        int64
+	Checksum    string
+}
+
+// List records the referenced files, it can be used by several goroutines.
+// Entries are appended to the files, so a resumed export continues the lists of the interrupted one.
+type List struct {
+	lock     sync.Mutex
+	files    *os.File
+	packages *os.File
+	images   *os.File
+}
+
+// Create opens the lists in the export directory
+func Create(exportDir string) (*List, error) {
+	list := &List{}
+	var err error
+	if list.files, err = openList(path.Join(exportDir, FileListName), fileListHeader); err != nil {
+		return nil, err
+	}
+	if list.packages, err = openList(path.Join(exportDir, PackageListName), ""); err != nil {
+		list.Close()
+		return nil, err
+	}
+	if list.images, err = openList(path.Join(exportDir, ImageListName), ""); err != nil {
+		list.Close()
+		return nil, err
+	}
+	return list, nil
+}
+
+func openList(fileName string, header string) (*os.File, error) {
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := file.Stat(); err == nil && info.Size() == 0 && len(header) > 0 {
+		_, err = file.WriteString(header + "\n")
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// AddPackage records the package file, relativePath is relative to the package directory of the source server
+func (l *List) AddPackage(source string, relativePath string, checksumType string, checksum string) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	entry := Entry{Source: source, Destination: relativePath, Size: info.Size(), Checksum: checksumType + ":" + checksum}
+	return l.add(entry, l.packages, relativePath)
+}
+
+// AddImage records the image file, relativePath is relative to the image directory of the source server
+func (l *List) AddImage(source string, relativePath string) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	checksum, err := utils.FileChecksum(source, imageChecksumType)
+	if err != nil {
+		return err
+	}
+	entry := Entry{Source: source, Destination: path.Join(ImagesDir, relativePath), Size: info.Size(),
+		Checksum: imageChecksumType + ":" + checksum}
+	return l.add(entry, l.images, relativePath)
+}
+
+// add writes every line by a single write, an interrupted export cannot leave a partial line followed by other entries
+func (l *List) add(entry Entry, rsyncList *os.File, relativePath string) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	line := strings.Join([]string{entry.Source, entry.Destination, strconv.FormatInt(entry.Size, 10), entry.Checksum}, "\t")
+	if _, err := l.files.WriteString(line + "\n"); err != nil {
+		return err
+	}
+	_, err := rsyncList.WriteString(relativePath + "\n")
+	return err
+}
+
+// Close closes all lists
+func (l *List) Close() error {
+	var firstErr error
+	for _, file := range []*os.File{l.files, l.packages, l.images} {
+		if file == nil {
+			continue
+		}
+		if err := file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Read reads the referenced files of the export directory, it returns nil when the export references no files
+func Read(exportDir string) ([]Entry, error) {
+	file, err := os.Open(path.Join(exportDir, FileListName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if line == fileListHeader || len(line) == 0 {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("invalid entry on line %d of %s", lineNumber, FileListName)
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size on line %d of %s: %w", lineNumber, FileListName, err)
+		}
+		entries = append(entries, Entry{Source: fields[0], Destination: fields[1], Size: size, Checksum: fields[3]})
+	}
+	return entries, scanner.Err()
+}
+
+// Verify checks the referenced files were delivered to the payload directory, it returns the problems sorted by file
+func Verify(payloadDir string, entries []Entry) []string {
+	problems := make([]string, 0)
+	verified := make(map[string]bool)
+	for _, entry := range entries {
+		// a resumed export can list a file twice
+		if verified[entry.Destination] {
+			continue
+		}
+		verified[entry.Destination] = true
+		if problem := verifyEntry(payloadDir, entry); len(problem) > 0 {
+			problems = append(problems, fmt.Sprintf("%s: %s", entry.Destination, problem))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// IsPayloadPath checks if the path relative to the payload directory is a package or image file
+func IsPayloadPath(relativePath string) bool {
+	return strings.HasPrefix(relativePath, "packages/") || strings.HasPrefix(relativePath, ImagesDir+"/")
+}
+
+// Write writes the content to target once its size and checksum match the entry.
+// The content is written to a temporary file next to target, which is renamed when it is verified.
+// With an empty target the content is only verified.
+func (e Entry) Write(content io.Reader, target string) error {
+	checksumType, checksum, found := strings.Cut(e.Checksum, ":")
+	if !found {
+		return fmt.Errorf("invalid checksum %s", e.Checksum)
+	}
+	checksumHash, err := utils.NewChecksumHash(checksumType)
+	if err != nil {
+		return err
+	}
+	var out io.Writer = checksumHash
+	var file *os.File
+	tmpFileName := target + ".tmp"
+	if len(target) > 0 {
+		if file, err = os.OpenFile(tmpFileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644); err != nil {
+			return err
+		}
+		defer os.Remove(tmpFileName)
+		defer file.Close()
+		out = io.MultiWriter(file, checksumHash)
+	}
+	size, err := io.Copy(out, content)
+	if err != nil {
+		return err
+	}
+	if size != e.Size {
+		return fmt.Errorf("%s: size %d, expected %d", e.Destination, size, e.Size)
+	}
+	if hex.EncodeToString(checksumHash.Sum(nil)) != strings.ToLower(checksum) {
+		return fmt.Errorf("%s: checksum mismatch", e.Destination)
+	}
+	if file == nil {
+		return nil
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFileName, target)
+}
+
+func verifyEntry(payloadDir string, entry Entry) string {
+	if path.IsAbs(entry.Destination) || strings.HasPrefix(path.Clean(entry.Destination), "..") {
+		return "destination outside of the payload directory"
+	}
+	info, err := os.Stat(path.Join(payloadDir, entry.Destination))
+	if os.IsNotExist(err) {
+		return "missing"
+	}
+	if err != nil {
+		return err.Error()
+	}
+	if info.Size() != entry.Size {
+		return fmt.Sprintf("size %d, expected %d", info.Size(), entry.Size)
+	}
+	checksumType, checksum, found := strings.Cut(entry.Checksum, ":")
+	if !found {
+		return fmt.Sprintf("invalid checksum %s", entry.Checksum)
+	}
+	matches, err := utils.FileMatchesChecksum(path.Join(payloadDir, entry.Destination), checksum, checksumType)
+	if err != nil {
+		return err.Error()
+	}
+	if !matches {
+		return "checksum mismatch"
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package payload
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, fileName string, content string) {
+	if err := os.MkdirAll(path.Dir(fileName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fileName, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPayloadList(t *testing.T) {
+	sourceDir := t.TempDir()
+	exportDir := t.TempDir()
+	payloadDir := t.TempDir()
+	writeFile(t, path.Join(sourceDir, "packages/1/a.rpm"), "package a")
+	writeFile(t, path.Join(sourceDir, "packages/1/b.rpm"), "package b")
+	writeFile(t, path.Join(sourceDir, "os-images/1/image.xz"), "image")
+
+	list, err := Create(exportDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sha256 of "package a", the checksum of b is wrong
+	if err := list.AddPackage(path.Join(sourceDir, "packages/1/a.rpm"), "packages/1/a.rpm", "sha256",
+		"7663fa2eaf2e6846391a250cc37947941ffda1650e53cdee850c32f56e277971"); err != nil {
+		t.Fatal(err)
+	}
+	if err := list.AddPackage(path.Join(sourceDir, "packages/1/b.rpm"), "packages/1/b.rpm", "sha256",
+		"0000"); err != nil {
+		t.Fatal(err)
+	}
+	if err := list.AddImage(path.Join(sourceDir, "os-images/1/image.xz"), "1/image.xz"); err != nil {
+		t.Fatal(err)
+	}
+	if err := list.AddPackage(path.Join(sourceDir, "packages/1/missing.rpm"), "packages/1/missing.rpm", "sha256", "0000"); err == nil {
+		t.Error("expected error for missing package file")
+	}
+	if err := list.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	packages, _ := os.ReadFile(path.Join(exportDir, PackageListName))
+	images, _ := os.ReadFile(path.Join(exportDir, ImageListName))
+	if string(packages) != "packages/1/a.rpm\npackages/1/b.rpm\n" || string(images) != "1/image.xz\n" {
+		t.Errorf("unexpected rsync lists %q %q", packages, images)
+	}
+
+	entries, err := Read(exportDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Entry{Source: path.Join(sourceDir, "os-images/1/image.xz"), Destination: "images/1/image.xz", Size: 5,
+		Checksum: "sha256:6105d6cc76af400325e94d588ce511be5bfdbb73b437dc51eca43917d7a43e3d"}
+	if len(entries) != 3 || !reflect.DeepEqual(entries[2], expected) {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+
+	writeFile(t, path.Join(payloadDir, "packages/1/a.rpm"), "package a")
+	writeFile(t, path.Join(payloadDir, "packages/1/b.rpm"), "package b")
+	writeFile(t, path.Join(payloadDir, "images/1/image.xz"), "images")
+	problems := Verify(payloadDir, entries)
+	expectedProblems := []string{"images/1/image.xz: size 6, expected 5", "packages/1/b.rpm: checksum mismatch"}
+	if !reflect.DeepEqual(problems, expectedProblems) {
+		t.Errorf("unexpected problems %v", problems)
+	}
+
+	if empty, err := Read(payloadDir); err != nil || empty != nil {
+		t.Errorf("unexpected entries of an export without referenced files: %v %v", empty, err)
+	}
+}
+
+func TestEntryWrite(t *testing.T) {
+	dir := t.TempDir()
+	target := path.Join(dir, "a.rpm")
+	// sha256 of "package a"
+	entry := Entry{Destination: "packages/1/a.rpm", Size: 9,
+		Checksum: "sha256:7663fa2eaf2e6846391a250cc37947941ffda1650e53cdee850c32f56e277971"}
+	if err := entry.Write(strings.NewReader("package b"), target); err == nil {
+		t.Error("expected checksum mismatch")
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("unverified content written: %v", err)
+	}
+	if err := entry.Write(strings.NewReader("package"), ""); err == nil {
+		t.Error("expected size mismatch")
+	}
+	if err := entry.Write(strings.NewReader("package a"), target); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(target); string(content) != "package a" {
+		t.Errorf("unexpected content %q", content)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Errorf("temporary files left: %v", files)
+	}
+}
@@ -0,0 +1,193 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schemareader
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SchemaFingerprintFileName is the file of the export with the fingerprint of the exported tables
+const SchemaFingerprintFileName = "schema_fingerprint.json"
+
+// TableFingerprint describes the parts of a table schema the exported statements depend on
+type TableFingerprint struct {
+	Columns       []string            `json:"columns"`
+	PKColumns     []string            `json:"pkColumns"`
+	UniqueIndexes map[string][]string `json:"uniqueIndexes"`
+	References    []string            `json:"references"`
+}
+
+// SchemaFingerprint is the fingerprint of all tables read during an export, keyed by table name
+type SchemaFingerprint map[string]TableFingerprint
+
+// Fingerprint computes the fingerprint of the tables. Virtual indexes come from the table rules and are left out.
+func Fingerprint(tables map[string]Table) SchemaFingerprint {
+	fingerprint := make(SchemaFingerprint, len(tables))
+	for name, table := range tables {
+		tableFingerprint := TableFingerprint{
+			Columns:       sortedCopy(table.Columns),
+			PKColumns:     make([]string, 0, len(table.PKColumns)),
+			UniqueIndexes: make(map[string][]string),
+			References:    make([]string, 0, len(table.References)),
+		}
+		for column := range table.PKColumns {
+			tableFingerprint.PKColumns = append(tableFingerprint.PKColumns, column)
+		}
+		sort.Strings(tableFingerprint.PKColumns)
+		for indexName, index := range table.UniqueIndexes {
+			if indexName == VirtualIndexName {
+				continue
+			}
+			tableFingerprint.UniqueIndexes[indexName] = sortedCopy(index.Columns)
+		}
+		for _, reference := range table.References {
+			tableFingerprint.References = append(tableFingerprint.References, formatReference(reference))
+		}
+		sort.Strings(tableFingerprint.References)
+		fingerprint[name] = tableFingerprint
+	}
+	return fingerprint
+}
+
+// formatReference returns the reference in the form table(column=referenced_column, ...)
+func formatReference(reference Reference) string {
+	mapping := make([]string, 0, len(reference.ColumnMapping))
+	for column, referencedColumn := range reference.ColumnMapping {
+		mapping = append(mapping, column+"="+referencedColumn)
+	}
+	sort.Strings(mapping)
+	return fmt.Sprintf("%s(%s)", reference.TableName, strings.Join(mapping, ", "))
+}
+
+func sortedCopy(values []string) []string {
+	result := append([]string{}, values...)
+	sort.Strings(result)
+	return result
+}
+
+// ReadTablesFingerprint computes the fingerprint of the tables in the database. Missing tables are left out.
+func ReadTablesFingerprint(db *sql.DB, tableNames []string) SchemaFingerprint {
+	tables := make(map[string]Table)
+	for _, tableName := range tableNames {
+		table, missing := processTable(db, tableName, true)
+		if missing {
+			continue
+		}
+		tables[table.Name] = table
+	}
+	return Fingerprint(tables)
+}
+
+// WriteSchemaFingerprint stores the fingerprint in the file
+func WriteSchemaFingerprint(fingerprint SchemaFingerprint, fileName string) error {
+	data, err := json.MarshalIndent(fingerprint, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, data, 0644)
+}
+
+// ReadSchemaFingerprint loads the fingerprint from the file
+func ReadSchemaFingerprint(fileName string) (SchemaFingerprint, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := make(SchemaFingerprint)
+	if err := json.Unmarshal(data, &fingerprint); err != nil {
+		return nil, fmt.Errorf("unable to parse schema fingerprint %s: %w", fileName, err)
+	}
+	return fingerprint, nil
+}
+
+// TableNames returns the sorted names of the tables in the fingerprint
+func (fingerprint SchemaFingerprint) TableNames() []string {
+	names := make([]string, 0, len(fingerprint))
+	for name := range fingerprint {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CompareFingerprints returns the differences of the current schema to the exported one, keyed by table name.
+// An empty result means the exported data can be imported into the current schema.
+func CompareFingerprints(exported SchemaFingerprint, current SchemaFingerprint) map[string][]string {
+	diff := make(map[string][]string)
+	for _, tableName := range exported.TableNames() {
+		exportedTable := exported[tableName]
+		currentTable, ok := current[tableName]
+		if !ok {
+			diff[tableName] = []string{"table is missing"}
+			continue
+		}
+		differences := make([]string, 0)
+		differences = append(differences, compareLists("column", exportedTable.Columns, currentTable.Columns)...)
+		differences = append(differences, compareLists("reference", exportedTable.References, currentTable.References)...)
+		if strings.Join(exportedTable.PKColumns, ",") != strings.Join(currentTable.PKColumns, ",") {
+			differences = append(differences, fmt.Sprintf("primary key changed from (%s) to (%s)",
+				strings.Join(exportedTable.PKColumns, ", "), strings.Join(currentTable.PKColumns, ", ")))
+		}
+		differences = append(differences, compareIndexes(exportedTable.UniqueIndexes, currentTable.UniqueIndexes)...)
+		if len(differences) > 0 {
+			diff[tableName] = differences
+		}
+	}
+	return diff
+}
+
+func compareLists(kind string, exported []string, current []string) []string {
+	differences := make([]string, 0)
+	currentSet := make(map[string]bool)
+	for _, value := range current {
+		currentSet[value] = true
+	}
+	exportedSet := make(map[string]bool)
+	for _, value := range exported {
+		exportedSet[value] = true
+		if !currentSet[value] {
+			differences = append(differences, fmt.Sprintf("- %s %s", kind, value))
+		}
+	}
+	for _, value := range current {
+		if !exportedSet[value] {
+			differences = append(differences, fmt.Sprintf("+ %s %s", kind, value))
+		}
+	}
+	return differences
+}
+
+func compareIndexes(exported map[string][]string, current map[string][]string) []string {
+	differences := make([]string, 0)
+	names := make([]string, 0)
+	for name := range exported {
+		names = append(names, name)
+	}
+	for name := range current {
+		if _, ok := exported[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		exportedColumns, inExported := exported[name]
+		currentColumns, inCurrent := current[name]
+		switch {
+		case !inCurrent:
+			differences = append(differences, fmt.Sprintf("- unique index %s (%s)", name, strings.Join(exportedColumns, ", ")))
+		case !inExported:
+			differences = append(differences, fmt.Sprintf("+ unique index %s (%s)", name, strings.Join(currentColumns, ", ")))
+		case strings.Join(exportedColumns, ",") != strings.Join(currentColumns, ","):
+			differences = append(differences, fmt.Sprintf("unique index %s changed from (%s) to (%s)", name,
+				strings.Join(exportedColumns, ", "), strings.Join(currentColumns, ", ")))
+		}
+	}
+	return differences
+}
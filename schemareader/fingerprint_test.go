@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schemareader
+
+import (
+	"path"
+	"reflect"
+	"testing"
+)
+
+func fingerprintTestTables() map[string]Table {
+	return map[string]Table{
+		"rhnchannel": {
+			Name:      "rhnchannel",
+			Columns:   []string{"id", "label", "org_id"},
+			PKColumns: map[string]bool{"id": true},
+			UniqueIndexes: map[string]UniqueIndex{
+				"rhn_channel_label_uq": {Name: "rhn_channel_label_uq", Columns: []string{"label"}},
+				VirtualIndexName:       {Name: VirtualIndexName, Columns: []string{"label", "org_id"}},
+			},
+			References: []Reference{{TableName: "web_customer", ColumnMapping: map[string]string{"org_id": "id"}}},
+		},
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	fingerprint := Fingerprint(fingerprintTestTables())
+
+	expected := SchemaFingerprint{
+		"rhnchannel": {
+			Columns:       []string{"id", "label", "org_id"},
+			PKColumns:     []string{"id"},
+			UniqueIndexes: map[string][]string{"rhn_channel_label_uq": {"label"}},
+			References:    []string{"web_customer(org_id=id)"},
+		},
+	}
+	if !reflect.DeepEqual(fingerprint, expected) {
+		t.Errorf("unexpected fingerprint: %v", fingerprint)
+	}
+}
+
+func TestSchemaFingerprintFile(t *testing.T) {
+	fingerprint := Fingerprint(fingerprintTestTables())
+	fileName := path.Join(t.TempDir(), SchemaFingerprintFileName)
+
+	if err := WriteSchemaFingerprint(fingerprint, fileName); err != nil {
+		t.Fatalf("unable to write fingerprint: %s", err)
+	}
+	read, err := ReadSchemaFingerprint(fileName)
+	if err != nil {
+		t.Fatalf("unable to read fingerprint: %s", err)
+	}
+	if !reflect.DeepEqual(read, fingerprint) {
+		t.Errorf("read fingerprint %v differs from written %v", read, fingerprint)
+	}
+}
+
+func TestCompareFingerprintsCompatible(t *testing.T) {
+	exported := Fingerprint(fingerprintTestTables())
+	current := Fingerprint(fingerprintTestTables())
+
+	if diff := CompareFingerprints(exported, current); len(diff) > 0 {
+		t.Errorf("unexpected differences: %v", diff)
+	}
+}
+
+func TestCompareFingerprintsDifferences(t *testing.T) {
+	exported := Fingerprint(fingerprintTestTables())
+	exported["rhnmissing"] = TableFingerprint{Columns: []string{"id"}}
+
+	tables := fingerprintTestTables()
+	changed := tables["rhnchannel"]
+	changed.Columns = []string{"id", "label", "org_id", "summary"}
+	changed.UniqueIndexes = map[string]UniqueIndex{
+		"rhn_channel_label_uq": {Name: "rhn_channel_label_uq", Columns: []string{"label", "org_id"}},
+	}
+	changed.References = []Reference{}
+	tables["rhnchannel"] = changed
+	current := Fingerprint(tables)
+
+	diff := CompareFingerprints(exported, current)
+
+	expected := map[string][]string{
+		"rhnchannel": {
+			"+ column summary",
+			"- reference web_customer(org_id=id)",
+			"unique index rhn_channel_label_uq changed from (label) to (label, org_id)",
+		},
+		"rhnmissing": {"table is missing"},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("unexpected differences: %v", diff)
+	}
+}
@@ -0,0 +1,292 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schemareader
+
+import (
+	"bytes"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// TableRule describes the adjustments of a table schema needed to export and import the table data
+type TableRule struct {
+	// free text explaining why the rule is needed
+	Comment string `json:"comment,omitempty"`
+	// sequence used to generate the primary key when it cannot be read from the column default
+	PKSequence string `json:"pkSequence,omitempty"`
+	// columns which are relevant only to the source server
+	UnexportColumns []string `json:"unexportColumns,omitempty"`
+	// columns of a virtual unique index used as natural key of the table
+	VirtualIndex []string `json:"virtualIndex,omitempty"`
+	// create the virtual index only when the table has no usable unique index
+	VirtualIndexIfMissing bool `json:"virtualIndexIfMissing,omitempty"`
+	// name of the unique index used as natural key of the table
+	MainUniqueIndex string `json:"mainUniqueIndex,omitempty"`
+	// the unique index containing this column is used as natural key of the table
+	MainUniqueIndexColumn string `json:"mainUniqueIndexColumn,omitempty"`
+	// columns added to existing unique indexes
+	ExtendUniqueIndexes map[string][]string `json:"extendUniqueIndexes,omitempty"`
+	// name of the row modifier applied to every exported row, see rowModifiers
+	RowModifier string `json:"rowModifier,omitempty"`
+	// foreign keys replaced by references to a different table
+	ReplaceReferences []ReferenceRule `json:"replaceReferences,omitempty"`
+	// the rows are identified by the unique key of the table referencing them, for tables without natural key
+	NaturalKeyFrom *NaturalKeyReference `json:"naturalKeyFrom,omitempty"`
+}
+
+// ReferenceRule replaces the foreign key to Table with a reference to ReplaceWith
+type ReferenceRule struct {
+	Table         string            `json:"table"`
+	ReplaceWith   string            `json:"replaceWith"`
+	ColumnMapping map[string]string `json:"columnMapping"`
+}
+
+// NaturalKeyReference identifies the rows of a table by the unique Key column of Table, which references them by Column
+type NaturalKeyReference struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	Key    string `json:"key"`
+}
+
+//go:embed tableRules.json
+var embeddedTableRules []byte
+
+// tableRules are the rules applied when reading the table schemas
+var tableRules = mustParseTableRules(embeddedTableRules)
+
+func mustParseTableRules(data []byte) map[string]TableRule {
+	rules, err := ParseTableRules(data)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded table rules: %s", err))
+	}
+	return rules
+}
+
+// ParseTableRules reads the rules keyed by table name and checks their format
+func ParseTableRules(data []byte) (map[string]TableRule, error) {
+	rules := make(map[string]TableRule)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("unable to parse table rules: %w", err)
+	}
+	for _, tableName := range sortedRuleNames(rules) {
+		if err := validateTableRule(tableName, rules[tableName]); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+func validateTableRule(tableName string, rule TableRule) error {
+	if len(tableName) == 0 || tableName != strings.ToLower(tableName) {
+		return fmt.Errorf("table rules: invalid table name %q, table names must be lowercase", tableName)
+	}
+	columnLists := map[string][]string{
+		"unexportColumns": rule.UnexportColumns,
+		"virtualIndex":    rule.VirtualIndex,
+	}
+	for index, columns := range rule.ExtendUniqueIndexes {
+		if len(columns) == 0 {
+			return fmt.Errorf("table rules for %s: no columns to add to unique index %s", tableName, index)
+		}
+		columnLists["extendUniqueIndexes."+index] = columns
+	}
+	for field, columns := range columnLists {
+		for _, column := range columns {
+			if len(column) == 0 {
+				return fmt.Errorf("table rules for %s: empty column name in %s", tableName, field)
+			}
+		}
+	}
+	if rule.VirtualIndexIfMissing && len(rule.VirtualIndex) == 0 {
+		return fmt.Errorf("table rules for %s: virtualIndexIfMissing requires virtualIndex columns", tableName)
+	}
+	mainIndexRules := 0
+	for _, set := range []bool{len(rule.VirtualIndex) > 0, len(rule.MainUniqueIndex) > 0, len(rule.MainUniqueIndexColumn) > 0, rule.NaturalKeyFrom != nil} {
+		if set {
+			mainIndexRules++
+		}
+	}
+	if mainIndexRules > 1 {
+		return fmt.Errorf("table rules for %s: only one of virtualIndex, mainUniqueIndex, mainUniqueIndexColumn and naturalKeyFrom can be set", tableName)
+	}
+	if key := rule.NaturalKeyFrom; key != nil && (len(key.Table) == 0 || len(key.Column) == 0 || len(key.Key) == 0) {
+		return fmt.Errorf("table rules for %s: naturalKeyFrom needs table, column and key", tableName)
+	}
+	if len(rule.RowModifier) > 0 {
+		if _, ok := rowModifiers[rule.RowModifier]; !ok {
+			return fmt.Errorf("table rules for %s: unknown row modifier %s", tableName, rule.RowModifier)
+		}
+	}
+	for _, reference := range rule.ReplaceReferences {
+		if len(reference.Table) == 0 || len(reference.ReplaceWith) == 0 || len(reference.ColumnMapping) == 0 {
+			return fmt.Errorf("table rules for %s: replaceReferences needs table, replaceWith and columnMapping", tableName)
+		}
+	}
+	return nil
+}
+
+// LoadTableRules reads the rules of the override file, if any, and merges them on top of the embedded rules.
+// Rules of a table in the override file replace the embedded rules of the same table, an empty rule disables them.
+// It returns the rules of the override file.
+func LoadTableRules(overrideFile string) (map[string]TableRule, error) {
+	if len(overrideFile) == 0 {
+		return map[string]TableRule{}, nil
+	}
+	data, err := os.ReadFile(overrideFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read table rules file %s: %w", overrideFile, err)
+	}
+	overrides, err := ParseTableRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", overrideFile, err)
+	}
+	tableRules = mergeTableRules(tableRules, overrides)
+	return overrides, nil
+}
+
+func mergeTableRules(rules map[string]TableRule, overrides map[string]TableRule) map[string]TableRule {
+	merged := make(map[string]TableRule, len(rules)+len(overrides))
+	for tableName, rule := range rules {
+		merged[tableName] = rule
+	}
+	for tableName, rule := range overrides {
+		merged[tableName] = rule
+	}
+	return merged
+}
+
+// ValidateTableRules checks the tables, columns and indexes used by the rules exist in the database
+func ValidateTableRules(db *sql.DB, rules map[string]TableRule) error {
+	for _, tableName := range sortedRuleNames(rules) {
+		columns := readColumnNames(db, tableName)
+		if len(columns) == 0 {
+			return fmt.Errorf("table rules: unknown table %s", tableName)
+		}
+		if err := validateTableRuleColumns(db, tableName, rules[tableName], columns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckBuiltinTableRules checks the built-in rules which are not overridden against the database.
+// The built-in rules cover several schema versions, the rules of tables missing in the database are skipped.
+// It returns the problems found, they are not fatal to survive schema changes.
+func CheckBuiltinTableRules(db *sql.DB, overrides map[string]TableRule) []string {
+	problems := make([]string, 0)
+	for _, tableName := range sortedRuleNames(tableRules) {
+		if _, ok := overrides[tableName]; ok {
+			continue
+		}
+		columns := readColumnNames(db, tableName)
+		if len(columns) == 0 {
+			problems = append(problems, fmt.Sprintf("table rules: skipping the built-in rules of nonexisting table %s", tableName))
+			delete(tableRules, tableName)
+			continue
+		}
+		if err := validateTableRuleColumns(db, tableName, tableRules[tableName], columns); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
+
+// validateTableRuleColumns checks the columns and indexes used by the rule of an existing table
+func validateTableRuleColumns(db *sql.DB, tableName string, rule TableRule, columns []string) error {
+	if column, ok := findUnknownColumn(rule, columns); !ok {
+		return fmt.Errorf("table rules for %s: unknown column %s", tableName, column)
+	}
+	if len(rule.MainUniqueIndex) > 0 || len(rule.ExtendUniqueIndexes) > 0 {
+		indexes := make(map[string]bool)
+		for _, index := range readUniqueIndexNames(db, tableName) {
+			indexes[index] = true
+		}
+		if len(rule.MainUniqueIndex) > 0 && !indexes[rule.MainUniqueIndex] {
+			return fmt.Errorf("table rules for %s: unknown unique index %s", tableName, rule.MainUniqueIndex)
+		}
+		for index := range rule.ExtendUniqueIndexes {
+			if !indexes[index] {
+				return fmt.Errorf("table rules for %s: unknown unique index %s", tableName, index)
+			}
+		}
+	}
+	for _, reference := range rule.ReplaceReferences {
+		replaceColumns := readColumnNames(db, reference.ReplaceWith)
+		if len(replaceColumns) == 0 {
+			return fmt.Errorf("table rules for %s: unknown table %s", tableName, reference.ReplaceWith)
+		}
+		mapped := make([]string, 0, len(reference.ColumnMapping))
+		for _, column := range reference.ColumnMapping {
+			mapped = append(mapped, column)
+		}
+		sort.Strings(mapped)
+		if column, ok := findUnknownColumn(TableRule{UnexportColumns: mapped}, replaceColumns); !ok {
+			return fmt.Errorf("table rules for %s: unknown column %s of %s", tableName, column, reference.ReplaceWith)
+		}
+	}
+	if key := rule.NaturalKeyFrom; key != nil {
+		keyColumns := readColumnNames(db, key.Table)
+		if len(keyColumns) == 0 {
+			return fmt.Errorf("table rules for %s: unknown table %s", tableName, key.Table)
+		}
+		if column, ok := findUnknownColumn(TableRule{UnexportColumns: []string{key.Column, key.Key}}, keyColumns); !ok {
+			return fmt.Errorf("table rules for %s: unknown column %s of %s", tableName, column, key.Table)
+		}
+	}
+	return nil
+}
+
+// findUnknownColumn returns the first column used by the rule which is not in the table columns
+func findUnknownColumn(rule TableRule, columns []string) (string, bool) {
+	known := make(map[string]bool)
+	for _, column := range columns {
+		known[column] = true
+	}
+	used := make([]string, 0)
+	used = append(used, rule.UnexportColumns...)
+	used = append(used, rule.VirtualIndex...)
+	if len(rule.MainUniqueIndexColumn) > 0 {
+		used = append(used, rule.MainUniqueIndexColumn)
+	}
+	for _, index := range sortedIndexNames(rule.ExtendUniqueIndexes) {
+		used = append(used, rule.ExtendUniqueIndexes[index]...)
+	}
+	for _, reference := range rule.ReplaceReferences {
+		for column := range reference.ColumnMapping {
+			used = append(used, column)
+		}
+	}
+	for _, column := range used {
+		if !known[column] {
+			return column, false
+		}
+	}
+	return "", true
+}
+
+func sortedRuleNames(rules map[string]TableRule) []string {
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedIndexNames(indexes map[string][]string) []string {
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,212 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schemareader
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/uyuni-project/inter-server-sync/tests"
+)
+
+func TestParseEmbeddedTableRules(t *testing.T) {
+	rules, err := ParseTableRules(embeddedTableRules)
+	if err != nil {
+		t.Fatalf("embedded table rules are invalid: %s", err)
+	}
+	if rules["rhnpackage"].PKSequence != "RHN_PACKAGE_ID_SEQ" {
+		t.Errorf("unexpected rhnpackage rule: %v", rules["rhnpackage"])
+	}
+}
+
+func TestParseTableRulesErrors(t *testing.T) {
+	cases := map[string]string{
+		`{"rhnpackage": {"virtualIndexes": ["name_id"]}}`:                         "unknown field",
+		`{"rhnpackage": {"rowModifier": "missing"}}`:                              "unknown row modifier missing",
+		`{"RhnPackage": {"pkSequence": "seq"}}`:                                   "must be lowercase",
+		`{"rhnpackage": {"virtualIndexIfMissing": true}}`:                         "requires virtualIndex",
+		`{"rhnpackage": {"virtualIndex": ["name_id", ""]}}`:                       "empty column name",
+		`{"rhnpackage": {"virtualIndex": ["name_id"], "mainUniqueIndex": "idx"}}`: "only one of",
+		`{"rhnpackage": {"replaceReferences": [{"table": "rhnregtoken"}]}}`:       "replaceReferences needs",
+		`{"rhnregtoken": {"naturalKeyFrom": {"table": "rhnactivationkey"}}}`:      "naturalKeyFrom needs",
+	}
+	for data, expected := range cases {
+		_, err := ParseTableRules([]byte(data))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("ParseTableRules(%s) returned error %v; expected %s", data, err, expected)
+		}
+	}
+}
+
+func TestMergeTableRules(t *testing.T) {
+	rules := map[string]TableRule{
+		"rhnpackage":    {PKSequence: "RHN_PACKAGE_ID_SEQ", VirtualIndex: []string{"name_id"}},
+		"rhnchecksum":   {PKSequence: "rhnchecksum_seq"},
+		"rhnconfigfile": {UnexportColumns: []string{"latest_config_revision_id"}},
+	}
+	overrides := map[string]TableRule{
+		"rhnpackage":    {VirtualIndex: []string{"name_id", "evr_id"}},
+		"rhnconfigfile": {},
+		"rhnerrata":     {MainUniqueIndex: "rhn_errata_adv_org_uq"},
+	}
+
+	merged := mergeTableRules(rules, overrides)
+
+	expected := map[string]TableRule{
+		"rhnpackage":    {VirtualIndex: []string{"name_id", "evr_id"}},
+		"rhnchecksum":   {PKSequence: "rhnchecksum_seq"},
+		"rhnconfigfile": {},
+		"rhnerrata":     {MainUniqueIndex: "rhn_errata_adv_org_uq"},
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("unexpected merged rules: %v", merged)
+	}
+	if len(rules["rhnpackage"].PKSequence) == 0 {
+		t.Errorf("original rules were modified")
+	}
+}
+
+func TestApplyTableFilters(t *testing.T) {
+	savedRules := tableRules
+	defer func() { tableRules = savedRules }()
+	tableRules = map[string]TableRule{
+		"virtual":   {PKSequence: "virtual_seq", VirtualIndex: []string{"a", "b"}, UnexportColumns: []string{"c"}},
+		"ifmissing": {VirtualIndex: []string{"a"}, VirtualIndexIfMissing: true},
+		"byColumn":  {MainUniqueIndexColumn: "b"},
+		"reference": {ReplaceReferences: []ReferenceRule{{Table: "old", ReplaceWith: "new", ColumnMapping: map[string]string{"a": "b"}}}},
+		"modifier":  {RowModifier: "initialSeverity"},
+	}
+	newTable := func(name string) Table {
+		return Table{
+			Name:                name,
+			UniqueIndexes:       map[string]UniqueIndex{"idx": {Name: "idx", Columns: []string{"b"}}},
+			MainUniqueIndexName: "idx",
+			References:          []Reference{{TableName: "old", ColumnMapping: map[string]string{"a": "id"}}, {TableName: "other"}},
+		}
+	}
+
+	table := applyTableFilters(newTable("virtual"))
+	if table.MainUniqueIndexName != VirtualIndexName || !reflect.DeepEqual(table.UniqueIndexes[VirtualIndexName].Columns, []string{"a", "b"}) {
+		t.Errorf("virtual index not applied: %v", table.UniqueIndexes)
+	}
+	if table.PKSequence != "virtual_seq" || !table.UnexportColumns["c"] {
+		t.Errorf("sequence or unexported columns not applied: %v", table)
+	}
+
+	table = applyTableFilters(newTable("ifmissing"))
+	if table.MainUniqueIndexName != "idx" {
+		t.Errorf("virtual index applied although the table has a unique index")
+	}
+	withoutIndex := newTable("ifmissing")
+	withoutIndex.MainUniqueIndexName = ""
+	if table = applyTableFilters(withoutIndex); table.MainUniqueIndexName != VirtualIndexName {
+		t.Errorf("virtual index not applied to table without unique index")
+	}
+
+	withoutIndex = newTable("byColumn")
+	withoutIndex.MainUniqueIndexName = ""
+	if table = applyTableFilters(withoutIndex); table.MainUniqueIndexName != "idx" {
+		t.Errorf("main unique index not found by column: %s", table.MainUniqueIndexName)
+	}
+
+	table = applyTableFilters(newTable("reference"))
+	expectedReferences := []Reference{{TableName: "new", ColumnMapping: map[string]string{"a": "b"}}, {TableName: "other"}}
+	if !reflect.DeepEqual(table.References, expectedReferences) {
+		t.Errorf("unexpected references: %v", table.References)
+	}
+
+	if table = applyTableFilters(newTable("modifier")); table.RowModCallback == nil {
+		t.Errorf("row modifier not applied")
+	}
+
+	if table = applyTableFilters(newTable("norule")); !reflect.DeepEqual(table, newTable("norule")) {
+		t.Errorf("table without rules was modified")
+	}
+}
+
+func TestValidateTableRules(t *testing.T) {
+	repo := tests.CreateDataRepository()
+	repo.ExpectWithRecords(ReadColumnNames, sqlmock.NewRows([]string{"column_name"}).AddRow("id").AddRow("label"), "rhnconfigfiletype")
+	repo.ExpectWithRecords(ReadColumnNames, sqlmock.NewRows([]string{"column_name"}).AddRow("id").AddRow("name"), "rhnpackagename")
+	repo.ExpectWithRecords(ReadColumnNames, sqlmock.NewRows([]string{"column_name"}), "rhnunknown")
+
+	err := ValidateTableRules(repo.DB, map[string]TableRule{
+		"rhnconfigfiletype": {VirtualIndex: []string{"label"}},
+		"rhnpackagename":    {UnexportColumns: []string{"name"}},
+		"rhnunknown":        {PKSequence: "seq"},
+	})
+	if err == nil || err.Error() != "table rules: unknown table rhnunknown" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	repo = tests.CreateDataRepository()
+	repo.ExpectWithRecords(ReadColumnNames, sqlmock.NewRows([]string{"column_name"}).AddRow("id").AddRow("label"), "rhnconfigfiletype")
+
+	err = ValidateTableRules(repo.DB, map[string]TableRule{
+		"rhnconfigfiletype": {VirtualIndex: []string{"label", "missing"}},
+	})
+	if err == nil || err.Error() != "table rules for rhnconfigfiletype: unknown column missing" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoadTableRulesMerged(t *testing.T) {
+	embedded := tableRules
+	defer func() { tableRules = embedded }()
+
+	rules, err := LoadTableRules("")
+	if err != nil || len(rules) > 0 || !reflect.DeepEqual(tableRules, embedded) {
+		t.Errorf("unexpected rules without override file: %v %v", rules, err)
+	}
+
+	overrideFile := path.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(overrideFile, []byte(`{"rhnunknown": {"pkSequence": "seq"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err = LoadTableRules(overrideFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules["rhnunknown"].PKSequence != "seq" {
+		t.Errorf("unexpected override rules: %v", rules)
+	}
+	if len(tableRules) != len(embedded)+1 || tableRules["rhnunknown"].PKSequence != "seq" {
+		t.Errorf("override rules not merged with the built-in rules")
+	}
+}
+
+func TestCheckBuiltinTableRules(t *testing.T) {
+	embedded := tableRules
+	defer func() { tableRules = embedded }()
+	tableRules = map[string]TableRule{
+		"rhncontentssl":     {VirtualIndex: []string{"content_source_id", "channel_family_id"}},
+		"rhnconfigfiletype": {VirtualIndex: []string{"label", "missing"}},
+		"rhnpackagename":    {UnexportColumns: []string{"unknown"}},
+	}
+
+	repo := tests.CreateDataRepository()
+	repo.ExpectWithRecords(ReadColumnNames, sqlmock.NewRows([]string{"column_name"}).AddRow("id").AddRow("label"), "rhnconfigfiletype")
+	repo.ExpectWithRecords(ReadColumnNames, sqlmock.NewRows([]string{"column_name"}), "rhncontentssl")
+
+	// rules of the override file are validated separately
+	problems := CheckBuiltinTableRules(repo.DB, map[string]TableRule{"rhnpackagename": {}})
+	expected := []string{
+		"table rules for rhnconfigfiletype: unknown column missing",
+		"table rules: skipping the built-in rules of nonexisting table rhncontentssl",
+	}
+	if !reflect.DeepEqual(problems, expected) {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+	if _, ok := tableRules["rhncontentssl"]; ok {
+		t.Error("rules of the nonexisting table not skipped")
+	}
+	if _, ok := tableRules["rhnconfigfiletype"]; !ok {
+		t.Error("rules of the existing table skipped")
+	}
+}
@@ -21,6 +21,8 @@ type Table struct {
 	References          []Reference
 	ReferencedBy        []Reference
 	RowModCallback      TableCallback
+	// set when the rows are identified by the unique key of the table referencing them
+	NaturalKeyFrom *NaturalKeyReference
 }
 
 // UniqueIndex represents an index among columns of a Table
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sqlUtil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Entity kinds marked in the exported SQL
+const (
+	EntityChannel       = "channel"
+	EntityConfigChannel = "config-channel"
+	EntityImage         = "image"
+)
+
+const (
+	entityBeginPrefix = "-- iss-entity-begin "
+	entityEndPrefix   = "-- iss-entity-end "
+)
+
+// EntityMarker delimits the statements of a single exported entity.
+// Markers are SQL comments, importers not aware of them just ignore them.
+type EntityMarker struct {
+	Begin bool
+	Kind  string
+	Label string
+}
+
+// EntityBeginMarker returns the comment line starting the statements of the entity
+func EntityBeginMarker(kind string, label string) string {
+	return fmt.Sprintf("%s%s %s\n", entityBeginPrefix, kind, label)
+}
+
+// EntityEndMarker returns the comment line ending the statements of the entity
+func EntityEndMarker(kind string, label string) string {
+	return fmt.Sprintf("%s%s %s\n", entityEndPrefix, kind, label)
+}
+
+// ParseEntityMarker reads the entity marker from the comment
+func ParseEntityMarker(comment string) (EntityMarker, bool) {
+	comment = strings.TrimSpace(comment)
+	marker := EntityMarker{}
+	switch {
+	case strings.HasPrefix(comment, entityBeginPrefix):
+		marker.Begin = true
+		comment = strings.TrimPrefix(comment, entityBeginPrefix)
+	case strings.HasPrefix(comment, entityEndPrefix):
+		comment = strings.TrimPrefix(comment, entityEndPrefix)
+	default:
+		return marker, false
+	}
+	parts := strings.SplitN(comment, " ", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return marker, false
+	}
+	marker.Kind = parts[0]
+	marker.Label = parts[1]
+	return marker, true
+}
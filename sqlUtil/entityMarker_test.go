@@ -0,0 +1,26 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sqlUtil
+
+import "testing"
+
+func TestParseEntityMarker(t *testing.T) {
+	cases := map[string]EntityMarker{
+		EntityBeginMarker(EntityChannel, "sles15-sp5-pool-x86_64"): {Begin: true, Kind: EntityChannel, Label: "sles15-sp5-pool-x86_64"},
+		EntityEndMarker(EntityImage, "my image:1.0.0"):             {Kind: EntityImage, Label: "my image:1.0.0"},
+	}
+	for comment, expected := range cases {
+		marker, ok := ParseEntityMarker(comment)
+		if !ok || marker != expected {
+			t.Errorf("ParseEntityMarker(%q) = %v, %t; expected %v", comment, marker, ok, expected)
+		}
+	}
+
+	for _, comment := range []string{"-- Channels", "-- iss-entity-begin channel", "INSERT INTO rhnchannel"} {
+		if _, ok := ParseEntityMarker(comment); ok {
+			t.Errorf("%q should not be an entity marker", comment)
+		}
+	}
+}
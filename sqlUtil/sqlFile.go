@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sqlUtil
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// SqlFileName is the compressed SQL file of an export, older exports have an uncompressed sql_statements.sql instead
+const SqlFileName = "sql_statements.sql.gz"
+
+// FindSqlFile returns the SQL file of the export directory, the compressed one if both exist
+func FindSqlFile(exportDir string) (string, error) {
+	sqlFile := path.Join(exportDir, SqlFileName)
+	_, err := os.Stat(sqlFile)
+	if os.IsNotExist(err) {
+		sqlFile = strings.TrimSuffix(sqlFile, ".gz")
+		_, err = os.Stat(sqlFile)
+	}
+	return sqlFile, err
+}
+
+// OpenSqlFile opens the SQL file, decompressing it if needed
+func OpenSqlFile(sqlFile string) (io.ReadCloser, error) {
+	return OpenSqlFileWith(sqlFile, func(file io.Reader) io.Reader { return file })
+}
+
+// OpenSqlFileWith opens the SQL file, wrapping the file reader before decompressing it
+func OpenSqlFileWith(sqlFile string, wrap func(io.Reader) io.Reader) (io.ReadCloser, error) {
+	file, err := os.Open(sqlFile)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(sqlFile, ".gz") {
+		return &sqlFileReader{Reader: wrap(file), file: file}, nil
+	}
+	gzipReader, err := gzip.NewReader(wrap(file))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &sqlFileReader{Reader: gzipReader, gzipReader: gzipReader, file: file}, nil
+}
+
+type sqlFileReader struct {
+	io.Reader
+	gzipReader *gzip.Reader
+	file       *os.File
+}
+
+func (r *sqlFileReader) Close() error {
+	if r.gzipReader != nil {
+		r.gzipReader.Close()
+	}
+	return r.file.Close()
+}
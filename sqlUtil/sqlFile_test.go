@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sqlUtil
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"testing"
+)
+
+func readSqlFile(t *testing.T, exportDir string) string {
+	sqlFile, err := FindSqlFile(exportDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := OpenSqlFile(sqlFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestOpenSqlFile(t *testing.T) {
+	exportDir := t.TempDir()
+	if _, err := FindSqlFile(exportDir); !os.IsNotExist(err) {
+		t.Errorf("unexpected error of an export without SQL file %v", err)
+	}
+
+	os.WriteFile(path.Join(exportDir, "sql_statements.sql"), []byte("plain"), 0644)
+	if content := readSqlFile(t, exportDir); content != "plain" {
+		t.Errorf("unexpected content %q", content)
+	}
+
+	file, _ := os.Create(path.Join(exportDir, SqlFileName))
+	gzipWriter := gzip.NewWriter(file)
+	gzipWriter.Write([]byte("compressed"))
+	gzipWriter.Close()
+	file.Close()
+	if content := readSqlFile(t, exportDir); content != "compressed" {
+		t.Errorf("unexpected content %q", content)
+	}
+}
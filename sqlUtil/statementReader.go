@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sqlUtil
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// StatementReader splits a SQL script into single statements.
+// Semicolons in quoted literals and identifiers, comments and dollar quoted blocks do not end a statement.
+type StatementReader struct {
+	reader   *bufio.Reader
+	count    int
+	comments []string
+	pending  []string
+}
+
+func NewStatementReader(reader io.Reader) *StatementReader {
+	return &StatementReader{reader: bufio.NewReaderSize(reader, 65536)}
+}
+
+// Count returns the number of statements read so far
+func (s *StatementReader) Count() int {
+	return s.count
+}
+
+// Comments returns the comments preceding the statement returned by the last call of Next.
+// After io.EOF it returns the comments at the end of the script.
+func (s *StatementReader) Comments() []string {
+	return s.comments
+}
+
+// Next returns the next statement without the terminating semicolon and leading comments.
+// It returns io.EOF once there are no statements left.
+func (s *StatementReader) Next() (string, error) {
+	var statement strings.Builder
+	for {
+		c, err := s.reader.ReadByte()
+		if err == io.EOF {
+			return s.complete(statement.String())
+		}
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case c == ';':
+			if text, err := s.complete(statement.String()); err == nil {
+				return text, nil
+			}
+			statement.Reset()
+			continue
+		case c == '\'':
+			statement.WriteByte(c)
+			err = s.readQuoted(&statement, '\'', isEscapeStringStart(statement.String()))
+		case c == '"':
+			statement.WriteByte(c)
+			err = s.readQuoted(&statement, '"', false)
+		case c == '-' && s.peekIs('-'):
+			statement.WriteByte(c)
+			err = s.readUntil(&statement, "\n")
+		case c == '/' && s.peekIs('*'):
+			statement.WriteByte(c)
+			err = s.readUntil(&statement, "*/")
+		case c == '$' && !endsWithIdentifier(statement.String()):
+			statement.WriteByte(c)
+			if tag, ok := s.readDollarTag(&statement); ok {
+				err = s.readUntil(&statement, tag)
+			}
+		default:
+			statement.WriteByte(c)
+		}
+		if err == io.EOF {
+			// unterminated literal or comment, the database reports the error
+			return s.complete(statement.String())
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// complete splits the leading comments from the statement, statements with comments only return io.EOF
+func (s *StatementReader) complete(statement string) (string, error) {
+	comments, text := splitLeadingComments(statement)
+	s.pending = append(s.pending, comments...)
+	s.comments = s.pending
+	if len(text) == 0 {
+		return "", io.EOF
+	}
+	s.pending = nil
+	s.count++
+	return text, nil
+}
+
+func (s *StatementReader) peekIs(c byte) bool {
+	next, err := s.reader.Peek(1)
+	return err == nil && next[0] == c
+}
+
+// readQuoted copies a quoted literal or identifier, doubled quotes are part of the value
+func (s *StatementReader) readQuoted(statement *strings.Builder, quote byte, backslashEscapes bool) error {
+	for {
+		c, err := s.reader.ReadByte()
+		if err != nil {
+			return err
+		}
+		statement.WriteByte(c)
+		if backslashEscapes && c == '\\' {
+			escaped, err := s.reader.ReadByte()
+			if err != nil {
+				return err
+			}
+			statement.WriteByte(escaped)
+			continue
+		}
+		if c == quote {
+			if s.peekIs(quote) {
+				next, _ := s.reader.ReadByte()
+				statement.WriteByte(next)
+				continue
+			}
+			return nil
+		}
+	}
+}
+
+// readUntil copies everything up to and including the terminator
+func (s *StatementReader) readUntil(statement *strings.Builder, terminator string) error {
+	for {
+		c, err := s.reader.ReadByte()
+		if err != nil {
+			return err
+		}
+		statement.WriteByte(c)
+		if c == terminator[len(terminator)-1] && strings.HasSuffix(statement.String(), terminator) {
+			return nil
+		}
+	}
+}
+
+// readDollarTag copies the rest of the dollar quote opening tag after the first $ and returns the whole tag
+func (s *StatementReader) readDollarTag(statement *strings.Builder) (string, bool) {
+	for length := 1; ; length++ {
+		peeked, err := s.reader.Peek(length)
+		if err != nil || len(peeked) < length {
+			return "", false
+		}
+		c := peeked[length-1]
+		if c == '$' {
+			tag := "$" + string(peeked)
+			s.reader.Discard(length)
+			statement.WriteString(tag[1:])
+			return tag, true
+		}
+		if !isIdentifierByte(c) || (length == 1 && c >= '0' && c <= '9') {
+			return "", false
+		}
+	}
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+}
+
+func endsWithIdentifier(text string) bool {
+	return len(text) > 0 && isIdentifierByte(text[len(text)-1])
+}
+
+// isEscapeStringStart checks whether the quote starting after the text opens an escape string constant with backslash escapes
+func isEscapeStringStart(text string) bool {
+	text = strings.TrimSuffix(text, "'")
+	if !strings.HasSuffix(text, "E") && !strings.HasSuffix(text, "e") {
+		return false
+	}
+	return !endsWithIdentifier(text[:len(text)-1])
+}
+
+// splitLeadingComments returns the comments at the beginning of the statement and the rest of the statement
+func splitLeadingComments(statement string) ([]string, string) {
+	comments := make([]string, 0)
+	for {
+		statement = strings.TrimSpace(statement)
+		var end int
+		switch {
+		case strings.HasPrefix(statement, "--"):
+			if end = strings.Index(statement, "\n"); end < 0 {
+				end = len(statement)
+			}
+		case strings.HasPrefix(statement, "/*"):
+			if end = strings.Index(statement, "*/"); end < 0 {
+				end = len(statement)
+			} else {
+				end += 2
+			}
+		default:
+			return comments, statement
+		}
+		comments = append(comments, statement[:end])
+		statement = statement[end:]
+	}
+}
+
+var statementTable = regexp.MustCompile(`(?i)^(?:INSERT\s+INTO|UPDATE|DELETE\s+FROM)\s+("?[\w.]+"?)`)
+
+// StatementTable returns the table modified by an INSERT, UPDATE or DELETE statement
+func StatementTable(statement string) string {
+	if match := statementTable.FindStringSubmatch(statement); match != nil {
+		return strings.ToLower(strings.Trim(match[1], `"`))
+	}
+	return ""
+}
+
+var statementType = regexp.MustCompile(`^\w+`)
+
+// StatementType returns the upper case command of the statement, like INSERT or DO
+func StatementType(statement string) string {
+	return strings.ToUpper(statementType.FindString(statement))
+}
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sqlUtil
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readAllStatements(t *testing.T, script string) []string {
+	reader := NewStatementReader(strings.NewReader(script))
+	statements := make([]string, 0)
+	for {
+		statement, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		statements = append(statements, statement)
+	}
+	if reader.Count() != len(statements) {
+		t.Errorf("Count() = %d; expected %d", reader.Count(), len(statements))
+	}
+	return statements
+}
+
+func TestStatementReader(t *testing.T) {
+	script := `BEGIN;
+-- Channels
+INSERT INTO rhnchannel (label, summary) VALUES ('test;1', 'it''s; fine');
+INSERT INTO rhnerrata (description) VALUES ( E'back\\slash\'; quote');
+UPDATE "rhnchannel" SET summary = 'x' WHERE label = 'test;1' /* comment; */;
+
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM rhnchannel WHERE label = 'a') THEN
+				RAISE EXCEPTION 'missing; channel';
+			END IF;
+		END $$;
+SELECT $tag$ text; with $$ $tag$;;
+COMMIT;
+-- trailing comment`
+
+	expected := []string{
+		"BEGIN",
+		"INSERT INTO rhnchannel (label, summary) VALUES ('test;1', 'it''s; fine')",
+		`INSERT INTO rhnerrata (description) VALUES ( E'back\\slash\'; quote')`,
+		`UPDATE "rhnchannel" SET summary = 'x' WHERE label = 'test;1' /* comment; */`,
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM rhnchannel WHERE label = 'a') THEN
+				RAISE EXCEPTION 'missing; channel';
+			END IF;
+		END $$`,
+		"SELECT $tag$ text; with $$ $tag$",
+		"COMMIT",
+	}
+	if statements := readAllStatements(t, script); !reflect.DeepEqual(statements, expected) {
+		t.Errorf("unexpected statements:\n%q\nexpected:\n%q", statements, expected)
+	}
+}
+
+func TestStatementReaderWithoutTerminator(t *testing.T) {
+	expected := []string{"SELECT 1", "SELECT 2"}
+	if statements := readAllStatements(t, "SELECT 1;\nSELECT 2\n"); !reflect.DeepEqual(statements, expected) {
+		t.Errorf("unexpected statements: %q", statements)
+	}
+}
+
+func TestStatementTable(t *testing.T) {
+	cases := map[string]string{
+		"INSERT INTO rhnchannel (id) VALUES (1)":         "rhnchannel",
+		"insert into rhnPackage (id) values (1)":         "rhnpackage",
+		"UPDATE susecontentproject SET first_env_id = 1": "susecontentproject",
+		`DELETE FROM "rhnerratapackage" WHERE 1 = 1`:     "rhnerratapackage",
+		"DO $$ BEGIN END $$":                             "",
+	}
+	for statement, expected := range cases {
+		if table := StatementTable(statement); table != expected {
+			t.Errorf("StatementTable(%s) = %s; expected %s", statement, table, expected)
+		}
+	}
+}
+
+func TestStatementType(t *testing.T) {
+	cases := map[string]string{
+		"insert into rhnchannel (id) values (1)": "INSERT",
+		"DO $$ BEGIN END $$":                     "DO",
+		"COMMIT":                                 "COMMIT",
+		"":                                       "",
+	}
+	for statement, expected := range cases {
+		if result := StatementType(statement); result != expected {
+			t.Errorf("StatementType(%s) = %s; expected %s", statement, result, expected)
+		}
+	}
+}
+
+func TestStatementReaderComments(t *testing.T) {
+	script := "-- first\nBEGIN;\n-- iss-entity-begin channel a\n/* block */ INSERT INTO x VALUES (1);\n;-- end\nCOMMIT;\n-- trailing\n"
+	reader := NewStatementReader(strings.NewReader(script))
+
+	expected := []struct {
+		statement string
+		comments  []string
+	}{
+		{"BEGIN", []string{"-- first"}},
+		{"INSERT INTO x VALUES (1)", []string{"-- iss-entity-begin channel a", "/* block */"}},
+		{"COMMIT", []string{"-- end"}},
+	}
+	for _, e := range expected {
+		statement, err := reader.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if statement != e.statement || !reflect.DeepEqual(reader.Comments(), e.comments) {
+			t.Errorf("got %q with comments %q; expected %q with comments %q", statement, reader.Comments(), e.statement, e.comments)
+		}
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+	if !reflect.DeepEqual(reader.Comments(), []string{"-- trailing"}) {
+		t.Errorf("unexpected trailing comments %q", reader.Comments())
+	}
+}
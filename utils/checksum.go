@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+// NewChecksumHash returns the hash computing checksums of the type, checksumType is a label of rhnchecksumtype like sha256
+func NewChecksumHash(checksumType string) (hash.Hash, error) {
+	switch strings.ToLower(checksumType) {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha384":
+		return sha512.New384(), nil
+	case "sha512":
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum type %s", checksumType)
+	}
+}
+
+// FileChecksum returns the hex encoded checksum of the file
+func FileChecksum(file string, checksumType string) (string, error) {
+	checksumHash, err := NewChecksumHash(checksumType)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(checksumHash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(checksumHash.Sum(nil)), nil
+}
+
+// FileMatchesChecksum checks the checksum of the file
+func FileMatchesChecksum(file string, checksum string, checksumType string) (bool, error) {
+	fileChecksum, err := FileChecksum(file, checksumType)
+	if err != nil {
+		return false, err
+	}
+	return fileChecksum == strings.ToLower(checksum), nil
+}
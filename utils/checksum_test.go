@@ -0,0 +1,28 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"os"
+	"path"
+	"testing"
+)
+
+func TestFileMatchesChecksum(t *testing.T) {
+	file := path.Join(t.TempDir(), "package.rpm")
+	if err := os.WriteFile(file, []byte("package"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sha256sum := "bc4a71180870f7945155fbb02f4b0a2e3faa2a62d6d31b7039013055ed19869a"
+	if matches, err := FileMatchesChecksum(file, sha256sum, "sha256"); err != nil || !matches {
+		t.Errorf("expected matching sha256 checksum, got %v %v", matches, err)
+	}
+	if matches, err := FileMatchesChecksum(file, "0123", "md5"); err != nil || matches {
+		t.Errorf("expected md5 checksum mismatch, got %v %v", matches, err)
+	}
+	if _, err := FileMatchesChecksum(file, sha256sum, "crc32"); err == nil {
+		t.Error("expected error for unsupported checksum type")
+	}
+}
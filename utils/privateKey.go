@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/youmark/pkcs8"
+)
+
+// ReadPrivateKey reads the PEM encoded private key. Encrypted keys, PKCS#8 or in the legacy OpenSSL format,
+// are decrypted with the passphrase on the first line of passfile.
+func ReadPrivateKey(key string, passfile string) (crypto.Signer, error) {
+	data, err := os.ReadFile(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read private key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded private key found in %s", key)
+	}
+	der := block.Bytes
+	// legacy OpenSSL encryption, insecure but created by older `openssl genrsa -aes256` and accepted by `openssl dgst -passin`
+	if x509.IsEncryptedPEMBlock(block) {
+		log.Warn().Msgf("Private key %s uses the insecure legacy OpenSSL encryption, convert it with "+
+			"`openssl pkcs8 -topk8 -v2 aes-256-cbc -in %s -out <new key>`", key, key)
+		if len(passfile) == 0 {
+			return nil, fmt.Errorf("private key %s is encrypted, use `--passfile` to set the file with its passphrase", key)
+		}
+		passphrase, err := ReadPassphrase(passfile)
+		if err != nil {
+			return nil, err
+		}
+		if der, err = x509.DecryptPEMBlock(block, passphrase); err != nil {
+			return nil, fmt.Errorf("unable to decrypt private key %s, wrong passphrase in %s or unsupported encryption: %w",
+				key, passfile, err)
+		}
+	}
+
+	var privateKey interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(der)
+	case "EC PRIVATE KEY":
+		privateKey, err = x509.ParseECPrivateKey(der)
+	case "PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS8PrivateKey(der)
+	case "ENCRYPTED PRIVATE KEY":
+		if len(passfile) == 0 {
+			return nil, fmt.Errorf("private key %s is encrypted, use `--passfile` to set the file with its passphrase", key)
+		}
+		passphrase, err := ReadPassphrase(passfile)
+		if err != nil {
+			return nil, err
+		}
+		if privateKey, err = pkcs8.ParsePKCS8PrivateKey(der, passphrase); err != nil {
+			return nil, fmt.Errorf("unable to decrypt private key %s, wrong passphrase in %s or unsupported encryption: %w",
+				key, passfile, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %s in private key %s", block.Type, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key %s: %w", key, err)
+	}
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %T of private key %s", privateKey, key)
+	}
+	return signer, nil
+}
+
+// ReadPassphrase returns the first line of the file, like `openssl -passin file:`
+func ReadPassphrase(passfile string) ([]byte, error) {
+	file, err := os.Open(passfile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read passphrase: %w", err)
+	}
+	defer file.Close()
+	line, err := bufio.NewReader(file).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return nil, fmt.Errorf("unable to read passphrase from %s: %w", passfile, err)
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog/log"
+)
+
+// SignatureExtension is appended to the name of the signed file to get the signature file.
+// Signatures are SHA-512 digests signed by the key, the same as created by `openssl dgst -sha512 -sign`.
+const SignatureExtension = ".sha512"
+
+// Sign file filePath by private key
+func SignFile(filePath string, key string, passfile string) error {
+	log.Info().Msgf("Signing %s using %s key", filePath, key)
+	privateKey, err := ReadPrivateKey(key, passfile)
+	if err != nil {
+		return err
+	}
+	digest, err := fileDigest(filePath)
+	if err != nil {
+		return err
+	}
+	var signature []byte
+	switch privateKey := privateKey.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(nil, privateKey, crypto.SHA512, digest)
+	case *ecdsa.PrivateKey:
+		signature, err = ecdsa.SignASN1(rand.Reader, privateKey, digest)
+	default:
+		return fmt.Errorf("unsupported type %T of private key %s, only RSA and ECDSA keys can be used", privateKey, key)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to sign %s: %w", filePath, err)
+	}
+	return os.WriteFile(filePath+SignatureExtension, signature, 0644)
+}
+
+// Validate file filePath by public certificate cert
+func ValidateFile(filePath string, cert string, cacert string) error {
+	if err := ValidateCertificate(cert, cacert); err != nil {
+		return err
+	}
+	return VerifySignature(filePath, cert)
+}
+
+// ValidateCertificate checks the certificate chain of cert. Certificates following the first one in cert
+// are used as intermediate certificates. The system CA certificates and the ones in cacert, if set, are trusted.
+func ValidateCertificate(cert string, cacert string) error {
+	log.Info().Msg("Verifying public certificate")
+	certificates, err := readCertificates(cert)
+	if err != nil {
+		return err
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		log.Debug().Err(err).Msg("system CA certificates not available")
+		roots = x509.NewCertPool()
+	}
+	if len(cacert) > 0 {
+		caCertificates, err := readCertificates(cacert)
+		if err != nil {
+			return err
+		}
+		for _, caCertificate := range caCertificates {
+			roots.AddCert(caCertificate)
+		}
+	}
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range certificates[1:] {
+		intermediates.AddCert(intermediate)
+	}
+	_, err = certificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return describeCertificateError(cert, certificates[0], err)
+}
+
+func describeCertificateError(cert string, certificate *x509.Certificate, err error) error {
+	var invalidError x509.CertificateInvalidError
+	var authorityError x509.UnknownAuthorityError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &invalidError) && invalidError.Reason == x509.Expired:
+		return fmt.Errorf("certificate %s is expired or not yet valid, it is valid from %s until %s",
+			cert, certificate.NotBefore.UTC(), certificate.NotAfter.UTC())
+	case errors.As(err, &authorityError):
+		return fmt.Errorf("untrusted chain, certificate %s issued by %q is not signed by a trusted CA, use `--ca` to set the CA certificate",
+			cert, certificate.Issuer)
+	default:
+		return fmt.Errorf("invalid certificate %s: %w", cert, err)
+	}
+}
+
+// VerifySignature checks the signature of file filePath was created by the private key of certificate cert
+func VerifySignature(filePath string, cert string) error {
+	log.Info().Msgf("Verifying %s using %s key", filePath, cert)
+	certificates, err := readCertificates(cert)
+	if err != nil {
+		return err
+	}
+	signature, err := os.ReadFile(filePath + SignatureExtension)
+	if err != nil {
+		return fmt.Errorf("unable to read signature of %s: %w", filePath, err)
+	}
+	digest, err := fileDigest(filePath)
+	if err != nil {
+		return err
+	}
+	verified := false
+	switch publicKey := certificates[0].PublicKey.(type) {
+	case *rsa.PublicKey:
+		verified = rsa.VerifyPKCS1v15(publicKey, crypto.SHA512, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		verified = ecdsa.VerifyASN1(publicKey, digest, signature)
+	default:
+		return fmt.Errorf("unsupported type %T of public key in certificate %s", publicKey, cert)
+	}
+	if !verified {
+		return fmt.Errorf("digest mismatch, %s was modified or not signed by the key of certificate %s", filePath, cert)
+	}
+	return nil
+}
+
+// readCertificates reads all PEM encoded certificates of the file
+func readCertificates(file string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read certificate: %w", err)
+	}
+	certificates := make([]*x509.Certificate, 0)
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate in %s: %w", file, err)
+		}
+		certificates = append(certificates, certificate)
+	}
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificate found in %s", file)
+	}
+	return certificates, nil
+}
+
+func fileDigest(filePath string) ([]byte, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	digest := sha512.New()
+	if _, err := io.Copy(digest, f); err != nil {
+		return nil, err
+	}
+	return digest.Sum(nil), nil
+}
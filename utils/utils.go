@@ -183,6 +183,20 @@ func ReadFileByLine(path string) []string {
 	return labels
 }
 
+// FormatBytes returns the size in bytes in a human readable form
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
 // ExecInteractivePrompt calls a command, expects an interactive prompt to start, passes the given input into it.
 func ExecInteractivePrompt(name string, input string) error {
 	cmd := exec.Command(name)
@@ -202,58 +216,3 @@ func checkError(err error, msg string) {
 		log.Fatal().Err(err).Msg(msg)
 	}
 }
-
-// Sign file filePath by private key cert
-func SignFile(filePath string, key string, passfile string) error {
-	signature := filePath + ".sha512"
-	log.Info().Msgf("Signing SQL export using %s key", key)
-	signCmd := []string{"openssl", "dgst", "--sha512", "-sign", key, "-out", signature}
-
-	if len(passfile) > 0 {
-		signCmd = append(signCmd, "-passin", "file:"+passfile)
-	}
-
-	signCmd = append(signCmd, filePath)
-
-	log.Debug().Msgf("Executing: %s", signCmd[:])
-	cmd := exec.Command(signCmd[0], signCmd[1:]...)
-	return cmd.Run()
-}
-
-// Validate file filePath by public certificate cert
-func ValidateFile(filePath string, cert string, cacert string) error {
-	signature := filePath + ".sha512"
-	log.Info().Msg("Verifying public certificate")
-	verifyCmd := []string{"openssl", "verify"}
-	if len(cacert) > 0 {
-		verifyCmd = append(verifyCmd, "-CAfile", cacert)
-	}
-	// Certificate needs to be the last option
-	verifyCmd = append(verifyCmd, cert)
-	log.Debug().Msgf("Executing: %s", verifyCmd[:])
-	cmd := exec.Command(verifyCmd[0], verifyCmd[1:]...)
-	if err := cmd.Run(); err != nil {
-		return err
-	}
-
-	log.Info().Msgf("Verifying SQL import using %s key", cert)
-
-	// generate temporary file just with cert pub key
-	pubkey, err := os.CreateTemp("", "pubkey-")
-	if err != nil {
-		return err
-	}
-	defer pubkey.Close()
-	defer os.Remove(pubkey.Name())
-	pubkeyCmd := []string{"openssl", "x509", "-pubkey", "-out", pubkey.Name(), "-in", cert}
-	log.Debug().Msgf("Executing: %s", pubkeyCmd[:])
-	cmd = exec.Command(pubkeyCmd[0], pubkeyCmd[1:]...)
-	if err := cmd.Run(); err != nil {
-		return err
-	}
-
-	verifyCmd = []string{"openssl", "dgst", "--sha512", "-verify", pubkey.Name(), "-signature", signature, filePath}
-	log.Debug().Msgf("Executing: %s", verifyCmd[:])
-	cmd = exec.Command(verifyCmd[0], verifyCmd[1:]...)
-	return cmd.Run()
-}
@@ -18,10 +18,17 @@ const (
 	Endpoint       = "http://localhost/rpc/api"
 	AuthMethod     = "auth.login"
 	SyncMethod     = "configchannel.syncSaltFilesOnDisk"
+
+	DeleteChannelMethod        = "channel.software.delete"
+	DeleteConfigChannelsMethod = "configchannel.deleteChannels"
+	DeleteImageMethod          = "image.delete"
 )
 
 type Client interface {
 	SyncConfigFiles(labels []string) (interface{}, error)
+	DeleteChannel(label string) (interface{}, error)
+	DeleteConfigChannels(labels []string) (interface{}, error)
+	DeleteImage(id int) (interface{}, error)
 }
 
 type client struct {
@@ -70,13 +77,28 @@ func timeoutDialer(connectTimeout, requestTimeout time.Duration) func(ctx contex
 	}
 }
 
-func (c *client) SyncConfigFiles(labels []string) (interface{}, error) {
-
+// executeAuthenticatedCall logs in and calls the method with the session token followed by args
+func (c *client) executeAuthenticatedCall(call string, args ...interface{}) (interface{}, error) {
 	credentials := []interface{}{c.username, c.password}
 	token, err := c.executeCall(c.endpoint, AuthMethod, credentials)
 	if err != nil {
 		return nil, err
 	}
-	syncPayload := []interface{}{token, labels}
-	return c.executeCall(c.endpoint, SyncMethod, syncPayload)
+	return c.executeCall(c.endpoint, call, append([]interface{}{token}, args...))
+}
+
+func (c *client) SyncConfigFiles(labels []string) (interface{}, error) {
+	return c.executeAuthenticatedCall(SyncMethod, labels)
+}
+
+func (c *client) DeleteChannel(label string) (interface{}, error) {
+	return c.executeAuthenticatedCall(DeleteChannelMethod, label)
+}
+
+func (c *client) DeleteConfigChannels(labels []string) (interface{}, error) {
+	return c.executeAuthenticatedCall(DeleteConfigChannelsMethod, labels)
+}
+
+func (c *client) DeleteImage(id int) (interface{}, error) {
+	return c.executeAuthenticatedCall(DeleteImageMethod, id)
 }